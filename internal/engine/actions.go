@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SubAction is one of several actions the LLM chose for a task at once,
+// with the fields that action uses.
+type SubAction struct {
+	Action     string   `json:"action"`
+	Priority   *int     `json:"priority,omitempty"`
+	NewContent *string  `json:"new_content,omitempty"`
+	Subtasks   []string `json:"subtasks,omitempty"`
+	Due        *string  `json:"due,omitempty"`
+}
+
+// subActionOrder is the order combined actions are applied in: the task's
+// text is settled before its priority and due date, subtasks are added once
+// the parent is final, and ice-box goes last since it moves the task away.
+// Actions not listed run after these.
+var subActionOrder = map[string]int{"recontextualize": 1, "reprioritize": 2, "defer": 3, "decompose": 4, "ice-box": 5}
+
+func subActionRank(action string) int {
+	if rank, ok := subActionOrder[action]; ok {
+		return rank
+	}
+	return len(subActionOrder) + 1
+}
+
+// withSubActions makes subs d's actions, in subActionOrder, dropping skips
+// and repeats of an action. The first becomes d.Action and every one's
+// fields are copied onto d, so a governor or report that looks at one
+// action sees the first and its values. d.Actions is only set when more
+// than one action remains; none leaves a skip.
+func withSubActions(d Decision, subs []SubAction) Decision {
+	var kept []SubAction
+	seen := make(map[string]bool)
+	for _, sub := range subs {
+		if sub.Action == "" || sub.Action == "skip" || seen[sub.Action] {
+			continue
+		}
+		seen[sub.Action] = true
+		kept = append(kept, sub)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return subActionRank(kept[i].Action) < subActionRank(kept[j].Action)
+	})
+
+	d.Action = "skip"
+	d.Priority, d.NewContent, d.Subtasks, d.Due = nil, nil, nil, nil
+	d.Actions = nil
+	for i, sub := range kept {
+		if i == 0 {
+			d.Action = sub.Action
+		}
+		if sub.Priority != nil {
+			d.Priority = sub.Priority
+		}
+		if sub.NewContent != nil {
+			d.NewContent = sub.NewContent
+		}
+		if sub.Subtasks != nil {
+			d.Subtasks = sub.Subtasks
+		}
+		if sub.Due != nil {
+			d.Due = sub.Due
+		}
+	}
+	if len(kept) > 1 {
+		d.Actions = kept
+	}
+	return d
+}
+
+// subDecisions splits a combined decision into one single-action decision
+// per sub-action, in the order they apply. Any other decision is returned
+// as is.
+func subDecisions(d Decision) []Decision {
+	if len(d.Actions) == 0 {
+		return []Decision{d}
+	}
+	decisions := make([]Decision, len(d.Actions))
+	for i, sub := range d.Actions {
+		single := d
+		single.Actions = nil
+		single.Action = sub.Action
+		single.Priority = sub.Priority
+		single.NewContent = sub.NewContent
+		single.Subtasks = sub.Subtasks
+		single.Due = sub.Due
+		decisions[i] = single
+	}
+	return decisions
+}
+
+// hasAction reports whether action is d's action or one of its combined
+// actions.
+func hasAction(d Decision, action string) bool {
+	if d.Action == action {
+		return true
+	}
+	for _, sub := range d.Actions {
+		if sub.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// governSubActions applies govern to each of d's actions as a single-action
+// decision and recombines what it leaves, so a governor written for one
+// action also holds for combined decisions. Actions govern turns into skips
+// are dropped and fields it changes are kept. Each call sees the reasoning
+// the previous one left, so every note govern adds survives. A
+// single-action decision is simply passed to govern.
+func governSubActions(d Decision, govern func(Decision) Decision) Decision {
+	if len(d.Actions) == 0 {
+		return govern(d)
+	}
+	reasoning := d.Reasoning
+	var kept []SubAction
+	for _, single := range subDecisions(d) {
+		single.Reasoning = reasoning
+		governed := govern(single)
+		reasoning = governed.Reasoning
+		if governed.Action == "skip" {
+			continue
+		}
+		kept = append(kept, SubAction{
+			Action:     governed.Action,
+			Priority:   governed.Priority,
+			NewContent: governed.NewContent,
+			Subtasks:   governed.Subtasks,
+			Due:        governed.Due,
+		})
+	}
+	d = withSubActions(d, kept)
+	d.Reasoning = reasoning
+	return d
+}
+
+// rawAction is an action as the LLM writes it, alone at the top level of
+// its answer or as an element of "actions".
+type rawAction struct {
+	Action     string    `json:"action"`
+	Priority   *Priority `json:"priority"`
+	NewContent *string   `json:"new_content"`
+	Subtasks   []string  `json:"subtasks"`
+	Due        *string   `json:"due"`
+}
+
+// resolve checks a and turns it into a SubAction: a defer's due date is made
+// absolute and an out-of-range priority is clamped, or refused without
+// Settings.ClampPriority. On error it also returns the reasoning for the
+// skip the task falls back to.
+func (a rawAction) resolve(taskID string) (SubAction, string, error) {
+	if a.Action == "defer" {
+		if a.Due == nil {
+			return SubAction{}, "defer without a due date", errors.New("defer decision has no due date")
+		}
+		due, err := ResolveDueDate(*a.Due, NowFunc())
+		if err != nil {
+			return SubAction{}, fmt.Sprintf("Bad due date: %v", err), err
+		}
+		a.Due = &due
+	}
+	if a.Action == "reprioritize" && a.Priority != nil && *a.Priority != PriorityNone && (*a.Priority < 1 || *a.Priority > 4) {
+		if !Settings.ClampPriority {
+			err := fmt.Errorf("priority %d out of range 1-4", *a.Priority)
+			return SubAction{}, err.Error(), err
+		}
+		clamped := Priority(min(max(int(*a.Priority), 1), 4))
+		Log.Warn("Clamped out-of-range priority %d to %d for task %s", *a.Priority, clamped, taskID)
+		a.Priority = &clamped
+	}
+	return SubAction{
+		Action:     a.Action,
+		Priority:   a.Priority.Int(),
+		NewContent: a.NewContent,
+		Subtasks:   a.Subtasks,
+		Due:        a.Due,
+	}, "", nil
+}
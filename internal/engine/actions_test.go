@@ -0,0 +1,118 @@
+package engine
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Combined Actions", func() {
+	const combined = `{"actions": [
+		{"action": "reprioritize", "priority": 2},
+		{"action": "recontextualize", "new_content": "Email Dana the Q3 draft"}
+	], "reasoning": "vague and underrated", "inertia_score": 6}`
+
+	It("should parse an actions list, rewrite first", func() {
+		decision, err := parseDecision(combined, "42")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Action).To(Equal("recontextualize"))
+		Expect(*decision.NewContent).To(Equal("Email Dana the Q3 draft"))
+		Expect(*decision.Priority).To(Equal(2))
+		Expect(decision.Reasoning).To(Equal("vague and underrated"))
+		Expect(decision.Actions).To(HaveLen(2))
+		Expect(decision.Actions[0].Action).To(Equal("recontextualize"))
+		Expect(decision.Actions[1].Action).To(Equal("reprioritize"))
+	})
+
+	It("should still read a single action", func() {
+		decision, err := parseDecision(`{"action": "reprioritize", "priority": 1, "reasoning": "due"}`, "42")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Action).To(Equal("reprioritize"))
+		Expect(decision.Actions).To(BeNil())
+
+		decision, err = parseDecision(`{"actions": [{"action": "defer", "due": "2026-03-01"}], "reasoning": "later"}`, "42")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Action).To(Equal("defer"))
+		Expect(decision.Actions).To(BeNil())
+	})
+
+	It("should skip the task when any of its actions is invalid", func() {
+		decision, err := parseDecision(`{"actions": [{"action": "reprioritize", "priority": 2}, {"action": "defer"}]}`, "42")
+		Expect(err).To(MatchError("defer decision has no due date"))
+		Expect(decision.Action).To(Equal("skip"))
+	})
+
+	It("should rewrite the task before reprioritizing it", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		decision, err := parseDecision(combined, "42")
+		Expect(err).NotTo(HaveOccurred())
+		decision.CurrentPriority = 4
+
+		Expect(ExecuteDecision(decision)).To(Succeed())
+		Expect(mock.CalledCommands).To(Equal([][]string{
+			{"td", "task", "update", "42", "--content", "Email Dana the Q3 draft"},
+			{"td", "task", "update", "42", "--priority", "p2"},
+		}))
+		Expect(PreviewDecision(decision)).To(Equal(mock.CalledCommands))
+		Expect(describeChange(decision)).To(Equal(`recontextualize -> "Email Dana the Q3 draft", then reprioritize -> p2`))
+	})
+
+	It("should keep only the allowed actions under --allow-actions", func() {
+		decision, err := parseDecision(combined, "42")
+		Expect(err).NotTo(HaveOccurred())
+
+		filtered := FilterExecutableActions([]Decision{decision}, []string{"reprioritize"})
+		Expect(filtered[0].Action).To(Equal("reprioritize"))
+		Expect(filtered[0].NewContent).To(BeNil())
+		Expect(filtered[0].Actions).To(BeNil())
+		Expect(downgradeToSkip(decision, "capped").Actions).To(BeNil())
+	})
+
+	Describe("under the governors", func() {
+		p := func(n int) *int { return &n }
+		combine := func(taskID string, subs ...SubAction) Decision {
+			return withSubActions(Decision{TaskID: taskID, Reasoning: "stale", CurrentPriority: 4}, subs)
+		}
+
+		It("should drop only the action a task is too young for", func() {
+			d := combine("t1", SubAction{Action: "decompose", Subtasks: []string{"Outline"}}, SubAction{Action: "reprioritize", Priority: p(2)})
+			d.AgeDays = 1
+
+			governed := ApplyAgeThresholds([]Decision{d}, 14, 30)[0]
+			Expect(governed.Action).To(Equal("reprioritize"))
+			Expect(governed.Actions).To(BeNil())
+			Expect(governed.Subtasks).To(BeNil())
+			Expect(governed.Reasoning).To(HavePrefix("task is 1 days old, decompose needs more than 14"))
+			Expect(PreviewDecision(governed)).To(Equal([][]string{{"td", "task", "update", "t1", "--priority", "p2"}}))
+		})
+
+		It("should demote the priority a combined decision actually sets", func() {
+			Settings.MaxUrgentPerProject = 1
+			rewrite := "Call the bank"
+			first := combine("t1", SubAction{Action: "reprioritize", Priority: p(1)})
+			first.InertiaScore = 9
+			second := combine("t2", SubAction{Action: "reprioritize", Priority: p(1)}, SubAction{Action: "recontextualize", NewContent: &rewrite})
+			ctxs := []TaskContext{{Task: Task{ID: "t1", ProjectID: "home"}}, {Task: Task{ID: "t2", ProjectID: "home"}}}
+
+			reconciled := ReconcileDecisions([]Decision{first, second}, ctxs)
+			Expect(*reconciled[1].Priority).To(Equal(2))
+			Expect(*reconciled[1].Actions[1].Priority).To(Equal(2))
+			Expect(reconciled[1].Reasoning).To(HavePrefix("demoted to p2"))
+			Expect(PreviewDecision(reconciled[1])).To(Equal([][]string{
+				{"td", "task", "update", "t2", "--content", "Call the bank"},
+				{"td", "task", "update", "t2", "--priority", "p2"},
+			}))
+		})
+
+		It("should keep a forced action that is not the first", func() {
+			d := combine("t1", SubAction{Action: "reprioritize", Priority: p(2)}, SubAction{Action: "decompose", Subtasks: []string{"Outline"}})
+			Expect(d.Action).To(Equal("reprioritize"))
+
+			forced := ApplyLabelOverrides([]Decision{d}, []Task{{ID: "t1", Labels: []string{"breakdown"}}}, map[string]string{"breakdown": "decompose"})[0]
+			Expect(forced.Action).To(Equal("decompose"))
+			Expect(forced.Subtasks).To(Equal([]string{"Outline"}))
+			Expect(forced.Priority).To(BeNil())
+			Expect(forced.Actions).To(BeNil())
+		})
+	})
+})
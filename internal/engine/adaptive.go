@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// slowFactor is how many times slower than the fastest call so far an LLM
+// call may be before the AdaptiveLimiter treats it as a sign of overload.
+const slowFactor = 3
+
+// AdaptiveLimiter bounds concurrent LLM work with a limit it tunes as it
+// goes, AIMD-style: each healthy call adds 1/limit, so the limit grows by
+// about one per round of calls, and each failed or unusually slow call
+// halves it. The limit starts at min and stays within [min, max].
+type AdaptiveLimiter struct {
+	mu       sync.Mutex
+	min, max int
+	limit    float64
+	inFlight int
+	fastest  time.Duration
+	// wake is closed, and replaced, whenever a slot may have opened up.
+	wake chan struct{}
+}
+
+func NewAdaptiveLimiter(min, max int) *AdaptiveLimiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &AdaptiveLimiter{min: min, max: max, limit: float64(min), wake: make(chan struct{})}
+}
+
+// Acquire blocks until fewer than Limit calls are in flight, or ctx is
+// cancelled.
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		wake := l.wake
+		l.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (l *AdaptiveLimiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.signal()
+}
+
+// Observe feeds back the outcome of one LLM call.
+func (l *AdaptiveLimiter) Observe(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil && (l.fastest == 0 || latency < l.fastest) {
+		l.fastest = latency
+	}
+	before := int(l.limit)
+	if err == nil && latency <= slowFactor*l.fastest {
+		l.limit = math.Min(l.limit+1/l.limit, float64(l.max))
+	} else {
+		l.limit = math.Max(l.limit/2, float64(l.min))
+	}
+	if after := int(l.limit); after != before {
+		Log.Debug("Adaptive concurrency %d -> %d", before, after)
+		if after > before {
+			l.signal()
+		}
+	}
+}
+
+// Limit is the current concurrency limit.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *AdaptiveLimiter) signal() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Adaptive Concurrency", func() {
+	healthy := func(l *AdaptiveLimiter, n int) {
+		for i := 0; i < n; i++ {
+			l.Observe(100*time.Millisecond, nil)
+		}
+	}
+
+	It("should start at the minimum and grow while calls stay healthy", func() {
+		l := NewAdaptiveLimiter(2, 8)
+		Expect(l.Limit()).To(Equal(2))
+		healthy(l, 3)
+		Expect(l.Limit()).To(Equal(3))
+		healthy(l, 100)
+		Expect(l.Limit()).To(Equal(8))
+	})
+
+	It("should halve the limit on an error, but not below the minimum", func() {
+		l := NewAdaptiveLimiter(2, 8)
+		healthy(l, 100)
+		l.Observe(100*time.Millisecond, errors.New("429 too many requests"))
+		Expect(l.Limit()).To(Equal(4))
+		l.Observe(100*time.Millisecond, errors.New("429 too many requests"))
+		l.Observe(100*time.Millisecond, errors.New("429 too many requests"))
+		Expect(l.Limit()).To(Equal(2))
+		healthy(l, 10)
+		Expect(l.Limit()).To(BeNumerically(">", 2))
+	})
+
+	It("should treat a call far slower than the fastest as overload", func() {
+		l := NewAdaptiveLimiter(1, 8)
+		healthy(l, 100)
+		l.Observe(250*time.Millisecond, nil)
+		Expect(l.Limit()).To(Equal(8))
+		l.Observe(time.Second, nil)
+		Expect(l.Limit()).To(Equal(4))
+	})
+
+	It("should block Acquire at the limit until a slot is released", func() {
+		l := NewAdaptiveLimiter(1, 1)
+		Expect(l.Acquire(context.Background())).To(Succeed())
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		Expect(l.Acquire(ctx)).To(MatchError(context.DeadlineExceeded))
+		l.Release()
+		Expect(l.Acquire(context.Background())).To(Succeed())
+	})
+
+	It("should bound in-flight tasks by the adaptive limit", func() {
+		var inFlight, peak atomic.Int64
+		Backend = backendFunc(func(string) (string, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			return "", errors.New("overloaded")
+		})
+		DeferCleanup(func() { Backend = defaultBackend() })
+		tasks := make([]Task, 20)
+		for i := range tasks {
+			tasks[i] = Task{ID: fmt.Sprint(i)}
+		}
+
+		l := NewAdaptiveLimiter(2, 10)
+		decisions := ProcessTasksParallel(tasks, &InertiaContext{}, 10, WithAdaptiveConcurrency(l))
+		Expect(decisions).To(HaveLen(20))
+		Expect(peak.Load()).To(BeNumerically("<=", 2))
+		Expect(l.Limit()).To(Equal(2))
+	})
+})
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AnonymizePeople are the people whose names --anonymize keeps out of
+// prompts; main sets it to the context's full people list so each person
+// gets the same placeholder in every prompt of a run. Nil disables it.
+var AnonymizePeople []Entity
+
+// personPlaceholder names the i-th person: Person A to Person Z, then
+// Person AA, Person AB and so on.
+func personPlaceholder(i int) string {
+	label := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = string(rune('A'+(i-1)%26)) + label
+	}
+	return "Person " + label
+}
+
+// Anonymize replaces every mention of a person, by name or alias, with a
+// placeholder chosen by their position in people, so the same list always
+// yields the same placeholders. It returns the rewritten prompt and a map
+// from each placeholder used back to the person's name.
+func Anonymize(prompt string, people []Entity) (string, map[string]string) {
+	type replacement struct {
+		name, placeholder, person string
+	}
+	var replacements []replacement
+	for i, person := range people {
+		for _, name := range person.names() {
+			if strings.TrimSpace(name) != "" {
+				replacements = append(replacements, replacement{name, personPlaceholder(i), person.Name})
+			}
+		}
+	}
+	// Longest first, so "Mary Jane" is replaced before "Mary".
+	sort.SliceStable(replacements, func(i, j int) bool {
+		return len(replacements[i].name) > len(replacements[j].name)
+	})
+	mapping := make(map[string]string)
+	for _, r := range replacements {
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(r.name) + `\b`)
+		if !pattern.MatchString(prompt) {
+			continue
+		}
+		prompt = pattern.ReplaceAllLiteralString(prompt, r.placeholder)
+		mapping[r.placeholder] = r.person
+	}
+	return prompt, mapping
+}
+
+// Deanonymize puts the real names from mapping back into text, such as the
+// LLM's reasoning about an anonymized prompt.
+func Deanonymize(text string, mapping map[string]string) string {
+	// Word boundaries keep "Person A" from matching inside "Person AB".
+	placeholders := make([]string, 0, len(mapping))
+	for p := range mapping {
+		placeholders = append(placeholders, p)
+	}
+	sort.Strings(placeholders)
+	for _, p := range placeholders {
+		text = regexp.MustCompile(`\b`+regexp.QuoteMeta(p)+`\b`).ReplaceAllLiteralString(text, mapping[p])
+	}
+	return text
+}
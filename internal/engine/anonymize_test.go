@@ -0,0 +1,57 @@
+package engine
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prompt Anonymization", func() {
+	people := []Entity{
+		{Name: "Dana Scully", Aliases: []string{"Dana"}},
+		{Name: "Fox Mulder", Aliases: []string{"Spooky"}},
+		{Name: "Walter Skinner"},
+	}
+
+	It("should replace names and aliases with stable placeholders", func() {
+		prompt, mapping := Anonymize("Task: Call Fox Mulder about Dana's report\nRelated People: Dana Scully; spooky", people)
+		Expect(prompt).To(Equal("Task: Call Person B about Person A's report\nRelated People: Person A; Person B"))
+		Expect(mapping).To(Equal(map[string]string{"Person A": "Dana Scully", "Person B": "Fox Mulder"}))
+
+		again, _ := Anonymize("Task: Lunch with Walter Skinner and Dana", people)
+		Expect(again).To(Equal("Task: Lunch with Person C and Person A"))
+	})
+
+	It("should map the LLM's references back to real names", func() {
+		_, mapping := Anonymize("Task: Call Fox Mulder about Dana's report", people)
+		Expect(Deanonymize("Person B is waiting on Person A; ask Person B first", mapping)).
+			To(Equal("Fox Mulder is waiting on Dana Scully; ask Fox Mulder first"))
+	})
+
+	It("should go past Z with two-letter placeholders", func() {
+		Expect(personPlaceholder(0)).To(Equal("Person A"))
+		Expect(personPlaceholder(25)).To(Equal("Person Z"))
+		Expect(personPlaceholder(26)).To(Equal("Person AA"))
+		Expect(personPlaceholder(27)).To(Equal("Person AB"))
+	})
+
+	It("should send placeholders to the LLM and restore names in the decision", func() {
+		var sent string
+		Backend = backendFunc(func(prompt string) (string, error) {
+			sent = prompt
+			return `{"action": "recontextualize", "new_content": "Email Person A the draft", "reasoning": "Person A asked for it"}`, nil
+		})
+		AnonymizePeople = people
+		DeferCleanup(func() {
+			Backend = defaultBackend()
+			AnonymizePeople = nil
+		})
+
+		decision, err := CallAgentForDecision(TaskContext{Task: Task{ID: "1", Content: "Send Dana the draft"}, RelatedPeople: people[:1]}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).NotTo(ContainSubstring("Dana"))
+		Expect(sent).To(ContainSubstring("Send Person A the draft"))
+		Expect(sent).To(ContainSubstring("Related people:\n- Person A: \n"))
+		Expect(*decision.NewContent).To(Equal("Email Dana Scully the draft"))
+		Expect(decision.Reasoning).To(Equal("Dana Scully asked for it"))
+	})
+})
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Approver decides, one action group at a time, whether decisions may
+// execute.
+type Approver interface {
+	Approve(action string, decisions []Decision) bool
+}
+
+// PromptApprover lists each group on Out and reads a y/N answer from In.
+// Answering "all" or "none" settles every remaining group without asking.
+// Running out of input counts as no.
+type PromptApprover struct {
+	In  io.Reader
+	Out io.Writer
+
+	scanner *bufio.Scanner
+	settled *bool
+}
+
+func (p *PromptApprover) Approve(action string, decisions []Decision) bool {
+	if p.settled != nil {
+		return *p.settled
+	}
+	if p.scanner == nil {
+		p.scanner = bufio.NewScanner(p.In)
+	}
+	fmt.Fprintf(p.Out, "%d %s decision(s):\n", len(decisions), action)
+	for _, d := range decisions {
+		fmt.Fprintf(p.Out, "  [%s] %s (inertia %.1f): %s\n", d.TaskID, describeChange(d), d.InertiaScore, d.Reasoning)
+	}
+	fmt.Fprintf(p.Out, "Execute these %d %s action(s)? [y/N/all/none] ", len(decisions), action)
+	if !p.scanner.Scan() {
+		fmt.Fprintln(p.Out)
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(p.scanner.Text())) {
+	case "y", "yes":
+		return true
+	case "a", "all":
+		yes := true
+		p.settled = &yes
+		return true
+	case "none":
+		no := false
+		p.settled = &no
+	}
+	return false
+}
+
+// describeChange summarizes what executing d would change, for review.
+func describeChange(d Decision) string {
+	if len(d.Actions) > 0 {
+		changes := make([]string, len(d.Actions))
+		for i, single := range subDecisions(d) {
+			changes[i] = describeChange(single)
+		}
+		return strings.Join(changes, ", then ")
+	}
+	switch {
+	case d.Priority != nil:
+		return fmt.Sprintf("%s -> %s", d.Action, NormalizePriority(*d.Priority, Settings.PriorityScale))
+	case d.NewContent != nil:
+		return fmt.Sprintf("%s -> %q", d.Action, *d.NewContent)
+	case d.Due != nil:
+		return fmt.Sprintf("%s -> due %s", d.Action, *d.Due)
+	case len(d.Subtasks) > 0:
+		return fmt.Sprintf("%s -> %s", d.Action, strings.Join(d.Subtasks, "; "))
+	}
+	return d.Action
+}
+
+// FilterApproved asks approver about each action in the order it first
+// appears and downgrades the decisions of rejected actions to skip.
+func FilterApproved(decisions []Decision, approver Approver) []Decision {
+	var actions []string
+	groups := make(map[string][]Decision)
+	for _, d := range decisions {
+		if d.Action == "skip" || d.Action == ActionFiltered {
+			continue
+		}
+		if _, ok := groups[d.Action]; !ok {
+			actions = append(actions, d.Action)
+		}
+		groups[d.Action] = append(groups[d.Action], d)
+	}
+	approved := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		approved[action] = approver.Approve(action, groups[action])
+	}
+
+	result := make([]Decision, len(decisions))
+	for i, d := range decisions {
+		if d.Action != "skip" && d.Action != ActionFiltered && !approved[d.Action] {
+			d = downgradeToSkip(d, "not approved")
+		}
+		result[i] = d
+	}
+	return result
+}
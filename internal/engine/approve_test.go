@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Approval Gate", func() {
+	p1 := 1
+	decisions := []Decision{
+		{TaskID: "1", Action: "reprioritize", Priority: &p1, Reasoning: "urgent"},
+		{TaskID: "2", Action: "skip"},
+		{TaskID: "3", Action: "ice-box", Reasoning: "stale"},
+		{TaskID: "4", Action: "reprioritize", Priority: &p1, Reasoning: "also urgent"},
+		{TaskID: "5", Action: "decompose", Subtasks: []string{"a", "b"}},
+	}
+	actions := func(ds []Decision) []string {
+		var out []string
+		for _, d := range ds {
+			out = append(out, d.Action)
+		}
+		return out
+	}
+
+	It("should execute only the action groups answered yes", func() {
+		var out bytes.Buffer
+		approver := &PromptApprover{In: strings.NewReader("y\nn\nyes\n"), Out: &out}
+
+		result := FilterApproved(decisions, approver)
+		Expect(actions(result)).To(Equal([]string{"reprioritize", "skip", "skip", "reprioritize", "decompose"}))
+		Expect(result[2].Reasoning).To(Equal("not approved (was ice-box: stale)"))
+		Expect(out.String()).To(ContainSubstring("2 reprioritize decision(s):\n  [1] reprioritize -> p1 (inertia 0.0): urgent\n"))
+		Expect(out.String()).To(ContainSubstring("Execute these 1 ice-box action(s)? [y/N/all/none] "))
+	})
+
+	It("should settle the remaining groups on all or none", func() {
+		var out bytes.Buffer
+		result := FilterApproved(decisions, &PromptApprover{In: strings.NewReader("all\n"), Out: &out})
+		Expect(actions(result)).To(Equal([]string{"reprioritize", "skip", "ice-box", "reprioritize", "decompose"}))
+		Expect(strings.Count(out.String(), "Execute these")).To(Equal(1))
+
+		result = FilterApproved(decisions, &PromptApprover{In: strings.NewReader("y\nnone\n"), Out: &out})
+		Expect(actions(result)).To(Equal([]string{"reprioritize", "skip", "skip", "reprioritize", "skip"}))
+	})
+
+	It("should treat a blank answer or missing input as no", func() {
+		result := FilterApproved(decisions, &PromptApprover{In: strings.NewReader("\n"), Out: &bytes.Buffer{}})
+		for _, d := range result {
+			Expect(d.Action).To(Equal("skip"))
+		}
+	})
+})
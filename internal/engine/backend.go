@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gavmor/inertia-engine/internal/runner"
+)
+
+// LLMBackend turns a decision prompt into the model's raw reply.
+type LLMBackend interface {
+	Decide(prompt string) (string, error)
+}
+
+// CommandBackend pipes the prompt to a CLI such as "openclaw chat" through
+// CommandRunner.
+type CommandBackend struct {
+	Name string
+	Args []string
+}
+
+// Decide streams the reply when CommandRunner supports it, stopping the
+// command as soon as a complete JSON object has arrived.
+func (b *CommandBackend) Decide(prompt string) (string, error) {
+	if sr, ok := CommandRunner.(runner.StreamRunner); ok {
+		stream, err := sr.RunWithStdinStream(prompt, b.Name, b.Args...)
+		if err != nil {
+			return "", err
+		}
+		output, readErr := StreamDecision(stream)
+		if err := stream.Close(); err != nil {
+			return output, err
+		}
+		return output, readErr
+	}
+	output, err := CommandRunner.RunWithStdin(prompt, b.Name, b.Args...)
+	return string(output), err
+}
+
+// maxStreamedReply bounds how much of a streamed LLM reply is read while
+// looking for the decision object.
+const maxStreamedReply = 1 << 20
+
+// StreamDecision reads r until the first balanced, valid JSON object is
+// complete and returns everything read up to and including it, leaving the
+// rest of the stream unread. Balanced braces in prose that aren't JSON are
+// read past. If r ends first, it returns what arrived so the caller can parse
+// or recover it.
+func StreamDecision(r io.Reader) (string, error) {
+	br := bufio.NewReader(r)
+	var sb strings.Builder
+	depth, objectStart := 0, 0
+	inString, escaped := false, false
+	for sb.Len() < maxStreamedReply {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return sb.String(), fmt.Errorf("read LLM output: %w", err)
+		}
+		sb.WriteByte(c)
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"' && depth > 0:
+			inString = !inString
+		case inString:
+		case c == '{':
+			if depth == 0 {
+				objectStart = sb.Len() - 1
+			}
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+			if depth == 0 && json.Valid([]byte(sb.String()[objectStart:])) {
+				return sb.String(), nil
+			}
+		}
+	}
+	return sb.String(), fmt.Errorf("LLM output exceeded %d bytes without a complete JSON object", maxStreamedReply)
+}
+
+// HTTPBackend posts the prompt to an OpenAI-compatible chat completions
+// endpoint, such as a local ollama server.
+type HTTPBackend struct {
+	URL    string
+	Model  string
+	Client *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (b *HTTPBackend) Decide(prompt string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:    b.Model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal chat request: %w", err)
+	}
+	endpoint := strings.TrimSuffix(b.URL, "/") + "/v1/chat/completions"
+	resp, err := b.Client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("post chat request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read chat response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("chat endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var chat chatResponse
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return "", fmt.Errorf("unmarshal chat response: %w", err)
+	}
+	if len(chat.Choices) == 0 {
+		return "", fmt.Errorf("chat response has no choices")
+	}
+	return chat.Choices[0].Message.Content, nil
+}
+
+func defaultBackend() LLMBackend {
+	return &CommandBackend{Name: "openclaw", Args: []string{"chat"}}
+}
+
+// NewBackend builds the backend selected by --llm-backend.
+func NewBackend(cfg *Config) (LLMBackend, error) {
+	switch cfg.LLMBackend {
+	case "", "command":
+		return defaultBackend(), nil
+	case "http":
+		return &HTTPBackend{URL: cfg.LLMURL, Model: cfg.LLMModel, Client: &http.Client{Timeout: 5 * time.Minute}}, nil
+	case "replay":
+		if cfg.Replay == "" {
+			return nil, fmt.Errorf("--llm-backend replay needs --replay <explain dir>")
+		}
+		return LoadReplayBackend(cfg.Replay)
+	}
+	return nil, fmt.Errorf("unknown LLM backend %q (want command, http or replay)", cfg.LLMBackend)
+}
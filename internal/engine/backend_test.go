@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// streamingMock is a StreamRunner whose stream is a pipe the test writes to.
+type streamingMock struct {
+	*MockRunner
+	stream *trackedStream
+}
+
+func (m *streamingMock) RunWithStdinStream(stdin string, name string, args ...string) (io.ReadCloser, error) {
+	m.MockRunner.RunWithStdin(stdin, name, args...)
+	return m.stream, nil
+}
+
+type trackedStream struct {
+	*io.PipeReader
+	closed chan struct{}
+}
+
+func (s *trackedStream) Close() error {
+	close(s.closed)
+	return s.PipeReader.Close()
+}
+
+var _ = Describe("LLM Backends", func() {
+	It("should decide through an OpenAI-compatible HTTP endpoint", func() {
+		var received chatRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/v1/chat/completions"))
+			Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+			w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"action\": \"ice-box\", \"reasoning\": \"dormant\"}"}}]}`))
+		}))
+		defer server.Close()
+
+		Backend = &HTTPBackend{URL: server.URL, Model: "llama3", Client: server.Client()}
+		DeferCleanup(func() { Backend = defaultBackend() })
+
+		decision, err := CallAgentForDecision(TaskContext{Task: Task{ID: "7", Content: "Learn the banjo"}}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Action).To(Equal("ice-box"))
+		Expect(decision.Reasoning).To(Equal("dormant"))
+		Expect(received.Model).To(Equal("llama3"))
+		Expect(received.Messages[0].Content).To(ContainSubstring("Learn the banjo"))
+	})
+
+	It("should surface non-200 responses as errors", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		backend := &HTTPBackend{URL: server.URL, Client: server.Client()}
+		_, err := backend.Decide("prompt")
+		Expect(err).To(MatchError(ContainSubstring("model not loaded")))
+	})
+
+	It("should pick the backend from config", func() {
+		cfg := DefaultConfig()
+		backend, err := NewBackend(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backend).To(BeAssignableToTypeOf(&CommandBackend{}))
+
+		cfg.LLMBackend = "http"
+		backend, err = NewBackend(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backend).To(BeAssignableToTypeOf(&HTTPBackend{}))
+
+		cfg.LLMBackend = "carrier-pigeon"
+		_, err = NewBackend(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when streaming a command's output", func() {
+		const reply = `{"action": "skip", "reasoning": "a {brace} and a \"quote}\" in text"}`
+
+		It("should stop reading once the decision object is complete", func() {
+			r, w := io.Pipe()
+			DeferCleanup(func() { r.Close() })
+			go func() {
+				io.WriteString(w, "Let me think about {this} task.\n")
+				io.WriteString(w, reply)
+				io.WriteString(w, "\nMore musing that never ends...")
+				io.WriteString(w, "still going")
+			}()
+
+			done := make(chan string)
+			go func() {
+				output, err := StreamDecision(r)
+				Expect(err).NotTo(HaveOccurred())
+				done <- output
+			}()
+			var output string
+			Eventually(done).WithTimeout(time.Second).Should(Receive(&output))
+			Expect(output).To(HaveSuffix(reply))
+			Expect(output).NotTo(ContainSubstring("More musing"))
+		})
+
+		It("should return whatever arrived when the stream ends early", func() {
+			output, err := StreamDecision(strings.NewReader(`prose {"action": "sk`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(`prose {"action": "sk`))
+		})
+
+		It("should close the command's stream early and decide from the streamed object", func() {
+			r, w := io.Pipe()
+			stream := &trackedStream{PipeReader: r, closed: make(chan struct{})}
+			CommandRunner = &streamingMock{MockRunner: &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}, stream: stream}
+			go func() {
+				io.WriteString(w, "Thinking... "+reply+" and then some")
+				w.Close()
+			}()
+
+			decision, err := CallAgentForDecision(TaskContext{Task: Task{ID: "7", Content: "Learn the banjo"}}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal("skip"))
+			Expect(decision.Reasoning).To(Equal(`a {brace} and a "quote}" in text`))
+			Expect(stream.closed).To(BeClosed())
+		})
+	})
+})
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+type CacheEntry struct {
+	Decision Decision  `json:"decision"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+// DecisionCache remembers LLM decisions keyed by a hash of the task's text
+// and the day's state, so unchanged tasks don't cost another LLM call.
+// Defer decisions aren't cached: their due date was resolved against the
+// day they were made and may have passed by a later run.
+type DecisionCache struct {
+	mu      sync.Mutex
+	path    string
+	Entries map[string]CacheEntry
+}
+
+// LoadDecisionCache reads the cache at path, starting empty if the file
+// doesn't exist yet.
+func LoadDecisionCache(path string) (*DecisionCache, error) {
+	cache := &DecisionCache{path: path, Entries: make(map[string]CacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &cache.Entries); err != nil {
+		return nil, fmt.Errorf("unmarshal cache: %w", err)
+	}
+	return cache, nil
+}
+
+func (c *DecisionCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.MarshalIndent(c.Entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
+	return nil
+}
+
+// Get returns the cached decision for task, unless the task was updated after
+// the decision was cached.
+func (c *DecisionCache) Get(task Task, state State) (Decision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.Entries[CacheKey(task, state)]
+	if !ok || task.UpdatedAt.After(entry.CachedAt) {
+		return Decision{}, false
+	}
+	decision := entry.Decision
+	decision.TaskID = task.ID
+	return decision, true
+}
+
+func (c *DecisionCache) Put(task Task, state State, decision Decision) {
+	if hasAction(decision, "defer") {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Entries[CacheKey(task, state)] = CacheEntry{Decision: decision, CachedAt: NowFunc()}
+}
+
+func CacheKey(task Task, state State) string {
+	h := sha256.New()
+	for _, part := range []string{task.Content, task.Description, state.Energy, state.Mood, state.Environment, state.WorkVolatility} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
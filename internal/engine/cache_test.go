@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decision Cache", func() {
+	var (
+		mock  *MockRunner
+		ctx   *InertiaContext
+		task  Task
+		cache *DecisionCache
+	)
+
+	BeforeEach(func() {
+		mock = &MockRunner{
+			Outputs: make(map[string][]byte),
+			Errors:  make(map[string]error),
+		}
+		CommandRunner = mock
+		ctx = &InertiaContext{State: State{Energy: "high"}}
+		task = Task{ID: "1", Content: "Write essay", UpdatedAt: NowFunc().Add(-time.Hour)}
+
+		var err error
+		cache, err = LoadDecisionCache(filepath.Join(GinkgoT().TempDir(), "cache.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Cache = cache
+		DeferCleanup(func() { Cache = nil })
+	})
+
+	It("should return a cached decision without calling the LLM", func() {
+		cache.Put(task, ctx.State, Decision{TaskID: "1", Action: "decompose", Subtasks: []string{"outline"}})
+
+		decision := ProcessTask(task, ctx)
+		Expect(decision.Action).To(Equal("decompose"))
+		Expect(mock.CalledCommands).To(BeEmpty())
+	})
+
+	It("should call the LLM when the task was updated after caching", func() {
+		cache.Put(task, ctx.State, Decision{TaskID: "1", Action: "decompose"})
+		task.UpdatedAt = NowFunc().Add(time.Hour)
+		mock.Outputs["openclaw"] = []byte(`{"action": "skip", "reasoning": "fine"}`)
+
+		decision := ProcessTask(task, ctx)
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(mock.CalledCommands).To(ContainElement([]string{"openclaw", "chat"}))
+	})
+
+	It("should populate the cache from successful LLM calls and persist it", func() {
+		mock.Outputs["openclaw"] = []byte(`{"action": "reprioritize", "priority": 2, "reasoning": "due soon"}`)
+		ProcessTask(task, ctx)
+		Expect(cache.Save()).To(Succeed())
+
+		reloaded, err := LoadDecisionCache(cache.path)
+		Expect(err).NotTo(HaveOccurred())
+		decision, ok := reloaded.Get(task, ctx.State)
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("reprioritize"))
+	})
+
+	It("should not cache defers, whose due dates go stale", func() {
+		mock.Outputs["openclaw"] = []byte(`{"action": "defer", "due": "+7d", "reasoning": "not this week"}`)
+		Expect(ProcessTask(task, ctx).Action).To(Equal("defer"))
+		Expect(cache.Entries).To(BeEmpty())
+
+		priority, due := 2, "2026-03-08"
+		cache.Put(task, ctx.State, withSubActions(Decision{TaskID: "1"}, []SubAction{
+			{Action: "reprioritize", Priority: &priority},
+			{Action: "defer", Due: &due},
+		}))
+		Expect(cache.Entries).To(BeEmpty())
+	})
+
+	It("should not cache failed LLM calls", func() {
+		mock.Outputs["openclaw"] = []byte(`not json`)
+		ProcessTask(task, ctx)
+		Expect(cache.Entries).To(BeEmpty())
+	})
+})
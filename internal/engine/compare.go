@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// scoreDiffTolerance is how far a task's inertia score may move between
+// reports before DiffReports lists it; the report tables show one decimal.
+const scoreDiffTolerance = 0.05
+
+// TaskChange is one task whose decision differs between two reports. An
+// empty OldAction or NewAction means the task is missing from that report.
+type TaskChange struct {
+	TaskID    string  `json:"task_id"`
+	OldAction string  `json:"old_action,omitempty"`
+	NewAction string  `json:"new_action,omitempty"`
+	OldScore  float64 `json:"old_score"`
+	NewScore  float64 `json:"new_score"`
+}
+
+// ReportDiff compares the decisions of two runs, for judging a prompt
+// change with --compare.
+type ReportDiff struct {
+	// Changes lists the tasks whose action or score moved, in the new
+	// report's order, then the tasks only the old report has.
+	Changes []TaskChange `json:"changes"`
+	// Compared counts the tasks in both reports; ActionChanges and
+	// ScoreChanges count those whose action changed, and those whose score
+	// moved under the same action.
+	Compared      int `json:"compared"`
+	ActionChanges int `json:"action_changes"`
+	ScoreChanges  int `json:"score_changes"`
+	Added         int `json:"added"`
+	Removed       int `json:"removed"`
+	// MeanScoreDelta is the average new-minus-old score over Compared.
+	MeanScoreDelta float64 `json:"mean_score_delta"`
+}
+
+// Churn is the share of compared tasks whose action changed.
+func (d ReportDiff) Churn() float64 {
+	if d.Compared == 0 {
+		return 0
+	}
+	return float64(d.ActionChanges) / float64(d.Compared)
+}
+
+// DiffReports matches old and new decisions by task ID.
+func DiffReports(old, new []Decision) ReportDiff {
+	var diff ReportDiff
+	before := make(map[string]Decision, len(old))
+	for _, d := range old {
+		before[d.TaskID] = d
+	}
+	seen := make(map[string]bool, len(new))
+	var totalDelta float64
+	for _, d := range new {
+		seen[d.TaskID] = true
+		prior, ok := before[d.TaskID]
+		if !ok {
+			diff.Added++
+			diff.Changes = append(diff.Changes, TaskChange{TaskID: d.TaskID, NewAction: d.Action, NewScore: d.InertiaScore})
+			continue
+		}
+		diff.Compared++
+		delta := d.InertiaScore - prior.InertiaScore
+		totalDelta += delta
+		switch {
+		case d.Action != prior.Action:
+			diff.ActionChanges++
+		case math.Abs(delta) >= scoreDiffTolerance:
+			diff.ScoreChanges++
+		default:
+			continue
+		}
+		diff.Changes = append(diff.Changes, TaskChange{
+			TaskID:    d.TaskID,
+			OldAction: prior.Action,
+			NewAction: d.Action,
+			OldScore:  prior.InertiaScore,
+			NewScore:  d.InertiaScore,
+		})
+	}
+	for _, d := range old {
+		if !seen[d.TaskID] {
+			diff.Removed++
+			diff.Changes = append(diff.Changes, TaskChange{TaskID: d.TaskID, OldAction: d.Action, OldScore: d.InertiaScore})
+		}
+	}
+	if diff.Compared > 0 {
+		diff.MeanScoreDelta = totalDelta / float64(diff.Compared)
+	}
+	return diff
+}
+
+// FormatReportDiff renders d as a table of changed tasks followed by the
+// churn totals.
+func FormatReportDiff(d ReportDiff) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tOLD\tNEW\tSCORE")
+	for _, c := range d.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.TaskID, orDash(c.OldAction), orDash(c.NewAction), scoreChange(c))
+	}
+	w.Flush()
+	fmt.Fprintf(&sb, "%d tasks compared: %d changed action (%.0f%% churn), %d changed score only, mean score change %+.2f; %d new, %d gone\n",
+		d.Compared, d.ActionChanges, 100*d.Churn(), d.ScoreChanges, d.MeanScoreDelta, d.Added, d.Removed)
+	return sb.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// scoreChange shows a score move as "4.0 -> 6.5", or the one score a task
+// present in a single report has.
+func scoreChange(c TaskChange) string {
+	switch {
+	case c.OldAction == "":
+		return fmt.Sprintf("%.1f", c.NewScore)
+	case c.NewAction == "":
+		return fmt.Sprintf("%.1f", c.OldScore)
+	}
+	return fmt.Sprintf("%.1f -> %.1f", c.OldScore, c.NewScore)
+}
+
+// LoadReport reads a report written by WriteReport.
+func LoadReport(path string) (RunReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RunReport{}, fmt.Errorf("read report: %w", err)
+	}
+	var report RunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return RunReport{}, fmt.Errorf("unmarshal report: %w", err)
+	}
+	return report, nil
+}
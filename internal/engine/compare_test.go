@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report Comparison", func() {
+	before := []Decision{
+		{TaskID: "1", Action: "skip", InertiaScore: 4},
+		{TaskID: "2", Action: "reprioritize", InertiaScore: 6},
+		{TaskID: "3", Action: "skip", InertiaScore: 5},
+		{TaskID: "4", Action: "ice-box", InertiaScore: 1},
+	}
+	after := []Decision{
+		{TaskID: "1", Action: "decompose", InertiaScore: 7},
+		{TaskID: "2", Action: "reprioritize", InertiaScore: 6.02},
+		{TaskID: "3", Action: "skip", InertiaScore: 3},
+		{TaskID: "5", Action: "skip", InertiaScore: 2},
+	}
+
+	It("should list the task that changed from skip to decompose", func() {
+		diff := DiffReports(before, after)
+		Expect(diff.Changes).To(Equal([]TaskChange{
+			{TaskID: "1", OldAction: "skip", NewAction: "decompose", OldScore: 4, NewScore: 7},
+			{TaskID: "3", OldAction: "skip", NewAction: "skip", OldScore: 5, NewScore: 3},
+			{TaskID: "5", NewAction: "skip", NewScore: 2},
+			{TaskID: "4", OldAction: "ice-box", OldScore: 1},
+		}))
+		Expect(diff.Compared).To(Equal(3))
+		Expect(diff.ActionChanges).To(Equal(1))
+		Expect(diff.ScoreChanges).To(Equal(1))
+		Expect(diff.Added).To(Equal(1))
+		Expect(diff.Removed).To(Equal(1))
+		Expect(diff.Churn()).To(BeNumerically("~", 1.0/3))
+		Expect(diff.MeanScoreDelta).To(BeNumerically("~", 0.34, 0.001))
+	})
+
+	It("should render the changes and churn totals", func() {
+		out := FormatReportDiff(DiffReports(before, after))
+		Expect(out).To(MatchRegexp(`1\s+skip\s+decompose\s+4\.0 -> 7\.0`))
+		Expect(out).To(MatchRegexp(`4\s+ice-box\s+-\s+1\.0`))
+		Expect(out).To(ContainSubstring("3 tasks compared: 1 changed action (33% churn), 1 changed score only, mean score change +0.34; 1 new, 1 gone"))
+	})
+
+	It("should load a report written by WriteReport", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "report.json")
+		Expect(WriteReport(path, RunReport{Date: "2026-02-24", Decisions: before})).To(Succeed())
+		report, err := LoadReport(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(DiffReports(report.Decisions, before).Changes).To(BeEmpty())
+	})
+})
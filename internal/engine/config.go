@@ -0,0 +1,432 @@
+package engine
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Weights are the inertia score components' shares of the 0-10 total.
+type Weights struct {
+	Historical  float64 `json:"historical"`
+	State       float64 `json:"state"`
+	Environment float64 `json:"environment"`
+	// Intention scales how far intention alignment moves the score away
+	// from neutral, so a day without intentions leaves scores unchanged.
+	Intention float64 `json:"intention"`
+}
+
+// Duration is a time.Duration that also accepts a day suffix ("14d"), used
+// for both flags and config file values.
+type Duration time.Duration
+
+// ParseDuration extends time.ParseDuration with whole or fractional days.
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) Set(s string) error {
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\" or \"14d\": %w", err)
+	}
+	return d.Set(s)
+}
+
+// StringList is a comma-separated list flag; in a config file it is a plain
+// array.
+type StringList []string
+
+func (l StringList) String() string {
+	return strings.Join(l, ",")
+}
+
+func (l *StringList) Set(s string) error {
+	*l = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// Config holds every run setting. Config file keys are the snake_case
+// spelling of the matching command-line flag.
+type Config struct {
+	Context        string   `json:"context"`
+	Source         string   `json:"source"`
+	TasksFile      string   `json:"tasks_file"`
+	DryRun         bool     `json:"dry_run"`
+	Interactive    bool     `json:"interactive"`
+	SkipPreflight  bool     `json:"skip_preflight"`
+	Concurrency    int      `json:"concurrency"`
+	MinTasks       int      `json:"min_tasks"`
+	AllowEmpty     bool     `json:"allow_empty"`
+	Report         string   `json:"report"`
+	ReportFiltered bool     `json:"report_filtered"`
+	Metrics        string   `json:"metrics"`
+	NotifyURL      string   `json:"notify_url"`
+	MaxPerProject  int      `json:"max_per_project"`
+	MinScore       float64  `json:"min_score"`
+	MaxSubtasks    int      `json:"max_subtasks"`
+	PlanOnOverflow bool     `json:"plan_on_overflow"`
+	GracePeriod    Duration `json:"grace_period"`
+	Deadline       Duration `json:"deadline"`
+	Cache          string   `json:"cache"`
+	NoCache        bool     `json:"no_cache"`
+	Anonymize      bool     `json:"anonymize"`
+	MaxMutations   int      `json:"max_mutations"`
+	Scorer         string   `json:"scorer"`
+	PrintPrompt    string   `json:"print_prompt"`
+	StateFile      string   `json:"state_file"`
+	ScoreOnly      bool     `json:"score_only"`
+	Sample         int      `json:"sample"`
+	Seed           int64    `json:"seed"`
+	RatePerMinute  int      `json:"rate_per_minute"`
+	IceBoxProject  string   `json:"ice_box_project"`
+	IceBoxName     string   `json:"ice_box_name"`
+	IceBoxStrategy string   `json:"icebox_strategy"`
+	IceBoxLabel    string   `json:"icebox_label"`
+	MinAge         Duration `json:"min_age"`
+	UndoLog        string   `json:"undo_log"`
+	Undo           string   `json:"undo"`
+	Journal        string   `json:"journal"`
+	Resume         string   `json:"resume"`
+	LogLevel       string   `json:"log_level"`
+	LogFormat      string   `json:"log_format"`
+	Verbose        bool     `json:"verbose"`
+	Quiet          bool     `json:"quiet"`
+	Output         string   `json:"output"`
+	Explain        string   `json:"explain"`
+	Replay         string   `json:"replay"`
+	Compare        string   `json:"compare"`
+	Deterministic  bool     `json:"deterministic"`
+	Examples       string   `json:"examples"`
+	LLMBackend     string   `json:"llm_backend"`
+	LLMURL         string   `json:"llm_url"`
+	LLMModel       string   `json:"llm_model"`
+	LLMRetries     int      `json:"llm_retries"`
+	FetchRetries   int      `json:"fetch_retries"`
+	ClampPriority  bool     `json:"clamp_priority"`
+	PromptPeople   bool     `json:"prompt_people"`
+
+	// RespectInlinePriority reprioritizes tasks whose content carries a
+	// marker like "URGENT:" or "(p3)" without asking the LLM.
+	RespectInlinePriority bool `json:"respect_inline_priority"`
+
+	// MaxUrgentPerProject caps how many tasks per project may be
+	// reprioritized to the most urgent level in one run; zero disables the
+	// cap. DedupeRewrites keeps only one of several tasks rewritten to the
+	// same text. See ReconcileDecisions.
+	MaxUrgentPerProject int  `json:"max_urgent_per_project"`
+	DedupeRewrites      bool `json:"dedupe_rewrites"`
+
+	// AdaptiveConcurrency tunes the number of concurrent LLM calls between
+	// MinConcurrency and Concurrency instead of always using Concurrency.
+	AdaptiveConcurrency bool `json:"adaptive_concurrency"`
+	MinConcurrency      int  `json:"min_concurrency"`
+
+	// InheritLabels and InheritPriority give decompose's new subtasks the
+	// parent task's labels and priority.
+	InheritLabels   bool `json:"inherit_labels"`
+	InheritPriority bool `json:"inherit_priority"`
+
+	// MaxTotalRetries caps LLM retries across the whole run; once spent,
+	// failed calls fall straight back to skip.
+	MaxTotalRetries int `json:"max_total_retries"`
+
+	// AllowActions restricts which actions execute; empty allows all.
+	AllowActions StringList `json:"allow_actions"`
+	// ExcludeLabels drops tasks carrying any of these labels before
+	// processing.
+	ExcludeLabels StringList `json:"exclude_labels"`
+
+	TrustLLMScore  bool    `json:"trust_llm_score"`
+	ScoreTolerance float64 `json:"score_tolerance"`
+	// FailFastOnParseErrors aborts the run on the first unparseable LLM
+	// response instead of skipping the task.
+	FailFastOnParseErrors bool `json:"fail_fast_on_parse_errors"`
+	// DecomposeAgeDays and IceBoxAgeDays are the task ages past which the
+	// prompt allows, and the governors accept, those actions.
+	DecomposeAgeDays int `json:"decompose_age_days"`
+	IceBoxAgeDays    int `json:"icebox_age_days"`
+	// IceBoxMaxWeight is the historical weight below which the icebox
+	// subcommand sweeps an old task.
+	IceBoxMaxWeight float64 `json:"icebox_max_weight"`
+	// HalfLifeDays is the task age at which historical weight has decayed
+	// to half; zero disables decay.
+	HalfLifeDays int `json:"half_life_days"`
+	// PriorityScale says whether 1 or 4 is urgent in fetched and LLM
+	// priorities; see NormalizePriority.
+	PriorityScale PriorityScale `json:"priority_scale"`
+	// WeightAggregator picks how matched concept spans combine into the
+	// historical weight: "max" or "boosted".
+	WeightAggregator string `json:"weight_aggregator"`
+
+	// ConceptMatchFraction is the share of a multi-word concept's words that
+	// must appear in a task when the exact phrase doesn't.
+	ConceptMatchFraction float64 `json:"concept_match_fraction"`
+	// SubtaskSimilarity is the TextSimilarity at which two subtasks of one
+	// decomposition count as duplicates; zero disables fuzzy matching.
+	SubtaskSimilarity float64 `json:"subtask_similarity"`
+	// MaxContextEntities caps the concepts and projects listed in each
+	// prompt, longest span first; zero lists them all.
+	MaxContextEntities int `json:"max_context_entities"`
+	// DuplicateSimilarity is the TextSimilarity at which two tasks are
+	// reported as duplicates; MergeDuplicates ice-boxes all but the oldest.
+	DuplicateSimilarity float64 `json:"duplicate_similarity"`
+	MergeDuplicates     bool    `json:"merge_duplicates"`
+	// MatchLabels includes task labels in the text matched against the
+	// gazetteer.
+	MatchLabels bool `json:"match_labels"`
+	// NormalizeMatching matches task text and entity names through
+	// NormalizeForMatch rather than plain lowercasing.
+	NormalizeMatching bool `json:"normalize_matching"`
+
+	// LabelOverrides forces an action on tasks carrying a label, whatever
+	// the LLM decides, e.g. {"pinned": "skip", "breakdown": "decompose"}.
+	// Map a label to "" to disable a default rule.
+	LabelOverrides map[string]string `json:"label_overrides"`
+	// LabelWeightBonuses adds to the historical weight of tasks carrying a
+	// label, e.g. {"health": 3, "career": 2}.
+	LabelWeightBonuses map[string]float64 `json:"label_weight_bonuses"`
+
+	// CommandTemplates replaces the td commands the td source runs, for a
+	// wrapped or aliased CLI; set it in the --config file.
+	CommandTemplates CommandTemplates `json:"command_templates"`
+
+	Weights             Weights             `json:"weights"`
+	EnvironmentKeywords map[string][]string `json:"environment_keywords"`
+	// Env sets extra environment variables per external command, keyed by
+	// command name, e.g. {"td": {"TODOIST_API_TOKEN": "..."}}.
+	Env map[string]map[string]string `json:"env"`
+}
+
+// CommandEnv flattens Env into sorted KEY=VALUE pairs per command, the form
+// runner.RealRunner takes.
+func (c *Config) CommandEnv() map[string][]string {
+	env := make(map[string][]string, len(c.Env))
+	for name, vars := range c.Env {
+		pairs := make([]string, 0, len(vars))
+		for k, v := range vars {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+		env[name] = pairs
+	}
+	return env
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Source:      "td",
+		Concurrency: 10,
+		MaxSubtasks: 8,
+		GracePeriod: Duration(30 * time.Second),
+		IceBoxName:  "Ice Box",
+		IceBoxLabel: "icebox",
+		LogLevel:    "info",
+		LogFormat:   "text",
+		Output:      "text",
+		LLMBackend:  "command",
+		LLMURL:      "http://localhost:11434",
+		LLMRetries:  1,
+
+		ScoreTolerance:       2,
+		WeightAggregator:     "max",
+		HalfLifeDays:         180,
+		DecomposeAgeDays:     14,
+		IceBoxAgeDays:        30,
+		IceBoxMaxWeight:      2,
+		MaxTotalRetries:      10,
+		FetchRetries:         2,
+		ClampPriority:        true,
+		DedupeRewrites:       true,
+		InheritLabels:        true,
+		MinConcurrency:       2,
+		InheritPriority:      true,
+		PromptPeople:         true,
+		PriorityScale:        PriorityDescending,
+		ConceptMatchFraction: 1,
+		SubtaskSimilarity:    0.8,
+		DuplicateSimilarity:  0.8,
+		MatchLabels:          true,
+		Weights:              Weights{Historical: 0.4, State: 0.3, Environment: 0.3, Intention: 0.2},
+		EnvironmentKeywords:  DefaultEnvironmentKeywords(),
+		CommandTemplates:     DefaultCommandTemplates(),
+		LabelOverrides:       map[string]string{"pinned": "skip", "noauto": "skip"},
+	}
+}
+
+// Settings is the active configuration consulted by the engine; main
+// replaces it once flags are parsed.
+var Settings = DefaultConfig()
+
+// LoadConfig reads a JSON or YAML (by .yaml/.yml extension) config file over
+// the defaults.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc map[string]any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("unmarshal yaml config: %w", err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("convert yaml config: %w", err)
+		}
+	}
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ParseFlags builds the run configuration from args. Precedence is flag,
+// then --config file, then built-in default.
+func ParseFlags(args []string) (*Config, error) {
+	var configPath string
+	probe := flag.NewFlagSet("inertia-engine", flag.ContinueOnError)
+	probe.SetOutput(io.Discard)
+	DefaultConfig().bindFlags(probe, &configPath)
+	probe.Parse(args)
+
+	cfg := DefaultConfig()
+	if configPath != "" {
+		loaded, err := LoadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+
+	fs := flag.NewFlagSet("inertia-engine", flag.ContinueOnError)
+	cfg.bindFlags(fs, &configPath)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// bindFlags registers a flag for every setting, defaulting to c's values.
+func (c *Config) bindFlags(fs *flag.FlagSet, configPath *string) {
+	fs.StringVar(configPath, "config", "", "JSON or YAML file providing defaults for these flags and scoring weights")
+	fs.StringVar(&c.Context, "context", c.Context, "Path to the inertia context JSON from phase 1, or - for stdin")
+	fs.StringVar(&c.Source, "source", c.Source, "Task manager to read and update: td (Todoist) or taskwarrior, or a comma-separated list to merge several")
+	fs.StringVar(&c.TasksFile, "tasks-file", c.TasksFile, "Read tasks from this JSON file (td task list --json shape) instead of the task source")
+	fs.BoolVar(&c.DryRun, "dry-run", c.DryRun, "Decide actions without executing td commands")
+	fs.BoolVar(&c.Interactive, "interactive", c.Interactive, "Review decisions and approve each action group before executing it")
+	fs.BoolVar(&c.SkipPreflight, "skip-preflight", c.SkipPreflight, "Don't check that td and the LLM command can be run before starting")
+	fs.IntVar(&c.Concurrency, "concurrency", c.Concurrency, "Maximum number of concurrent LLM calls")
+	fs.BoolVar(&c.AdaptiveConcurrency, "adaptive-concurrency", c.AdaptiveConcurrency, "Start at --min-concurrency and raise concurrency up to --concurrency while LLM calls stay fast and succeed, halving it on errors")
+	fs.IntVar(&c.MinConcurrency, "min-concurrency", c.MinConcurrency, "Lower bound, and starting point, for --adaptive-concurrency")
+	fs.IntVar(&c.MinTasks, "min-tasks", c.MinTasks, "Abort if fewer than this many leaf tasks are fetched (0 disables the check)")
+	fs.BoolVar(&c.AllowEmpty, "allow-empty", c.AllowEmpty, "With --min-tasks, still proceed when no tasks are fetched at all")
+	fs.StringVar(&c.Report, "report", c.Report, "Write a JSON decision report to this path")
+	fs.BoolVar(&c.ReportFiltered, "report-filtered", c.ReportFiltered, "Include tasks excluded before processing in the report")
+	fs.StringVar(&c.Metrics, "metrics", c.Metrics, "Write run metrics to this path (Prometheus textfile, or JSON if it ends in .json)")
+	fs.StringVar(&c.NotifyURL, "notify-url", c.NotifyURL, "POST a JSON run summary to this webhook (e.g. a Slack incoming webhook) when the run completes")
+	fs.IntVar(&c.MaxPerProject, "max-per-project", c.MaxPerProject, "Maximum actionable decisions per project (0 for no cap)")
+	fs.IntVar(&c.MaxUrgentPerProject, "max-urgent-per-project", c.MaxUrgentPerProject, "Maximum tasks per project reprioritized to the most urgent level; the rest are demoted a level (0 for no cap)")
+	fs.BoolVar(&c.InheritLabels, "inherit-labels", c.InheritLabels, "Give subtasks added by decompose the parent's labels (--inherit-labels=false to disable)")
+	fs.BoolVar(&c.InheritPriority, "inherit-priority", c.InheritPriority, "Give subtasks added by decompose the parent's priority (--inherit-priority=false to disable)")
+	fs.BoolVar(&c.DedupeRewrites, "dedupe-rewrites", c.DedupeRewrites, "Keep only the highest-scoring of several tasks rewritten to the same text (--dedupe-rewrites=false to disable)")
+	fs.Float64Var(&c.MinScore, "min-score", c.MinScore, "Downgrade actions with a lower inertia score to skip")
+	fs.Var(&c.AllowActions, "allow-actions", "Comma-separated actions allowed to execute; others are downgraded to skip (default all)")
+	fs.Var(&c.ExcludeLabels, "exclude-labels", "Comma-separated labels whose tasks are left out of the run, e.g. waiting,someday (case-insensitive)")
+	fs.IntVar(&c.MaxSubtasks, "max-subtasks", c.MaxSubtasks, "Most subtasks one decompose may add (0 = unlimited)")
+	fs.BoolVar(&c.PlanOnOverflow, "plan-on-overflow", c.PlanOnOverflow, "Add a single \"Plan: <task>\" subtask instead of truncating an oversized decompose")
+	fs.Var(&c.GracePeriod, "grace-period", "How long to wait for in-flight tasks after an interrupt")
+	fs.Var(&c.Deadline, "deadline", "Stop processing after this long, then act on the decisions made so far (e.g. 20m)")
+	fs.StringVar(&c.Cache, "cache", c.Cache, "Cache LLM decisions in this JSON file across runs")
+	fs.StringVar(&c.StateFile, "state-file", c.StateFile, "Only process tasks updated since the run that last wrote this JSON file, plus any never decided on")
+	fs.BoolVar(&c.NoCache, "no-cache", c.NoCache, "Ignore --cache and always call the LLM")
+	fs.BoolVar(&c.ScoreOnly, "score-only", c.ScoreOnly, "Print tasks ranked by inertia score and exit without executing anything")
+	fs.IntVar(&c.Sample, "sample", c.Sample, "Process only N random leaf tasks, print an action histogram and execute nothing")
+	fs.Int64Var(&c.Seed, "seed", c.Seed, "Random seed for --sample (0 picks one and logs it)")
+	fs.IntVar(&c.RatePerMinute, "rate-per-minute", c.RatePerMinute, "Maximum LLM calls per minute (0 for no limit)")
+	fs.IntVar(&c.LLMRetries, "llm-retries", c.LLMRetries, "Retry a failed LLM call up to this many times")
+	fs.IntVar(&c.FetchRetries, "fetch-retries", c.FetchRetries, "Retry a transiently failing task fetch up to this many times, with backoff")
+	fs.BoolVar(&c.ClampPriority, "clamp-priority", c.ClampPriority, "Clamp an LLM priority outside 1-4 into range instead of skipping the task (--clamp-priority=false to skip)")
+	fs.BoolVar(&c.RespectInlinePriority, "respect-inline-priority", c.RespectInlinePriority, "Reprioritize tasks marked \"URGENT:\" or \"(p3)\" in their content to that priority without calling the LLM")
+	fs.IntVar(&c.MaxTotalRetries, "max-total-retries", c.MaxTotalRetries, "Cap on LLM retries across the whole run (0 disables retries)")
+	fs.StringVar(&c.IceBoxProject, "ice-box-project", c.IceBoxProject, "ID of the ice-box project whose tasks are never reprocessed")
+	fs.StringVar(&c.IceBoxName, "ice-box-name", c.IceBoxName, "Name used to look up the ice-box project when --ice-box-project is unset")
+	fs.StringVar(&c.IceBoxStrategy, "icebox-strategy", c.IceBoxStrategy, "How to ice-box a task: project (move it to the ice-box project) or label (tag it with --icebox-label)")
+	fs.StringVar(&c.IceBoxLabel, "icebox-label", c.IceBoxLabel, "Label added to ice-boxed tasks with --icebox-strategy label")
+	fs.Var(&c.MinAge, "min-age", "Only process tasks older than this, e.g. 14d or 720h")
+	fs.StringVar(&c.UndoLog, "undo-log", c.UndoLog, "Record executed mutations to this JSON file for later --undo")
+	fs.StringVar(&c.Undo, "undo", c.Undo, "Revert the mutations recorded in this undo log, then exit")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "Minimum log level: debug, info, warn or error")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "Log output format: text or json")
+	fs.BoolVar(&c.Verbose, "verbose", c.Verbose, "Log the people, projects and concepts each task matched")
+	fs.BoolVar(&c.Quiet, "quiet", c.Quiet, "Only log warnings and errors, and skip the progress line and summary table; the report path is still printed")
+	fs.StringVar(&c.Output, "output", c.Output, "Decision output on stdout: text (log lines) or json (one object per line)")
+	fs.StringVar(&c.Explain, "explain", c.Explain, "Write each task's prompt and raw LLM response to this directory")
+	fs.StringVar(&c.Journal, "journal", c.Journal, "Record each decision's execution status (pending, done or failed) in this JSON Lines file as the run goes")
+	fs.StringVar(&c.Resume, "resume", c.Resume, "Execute the decisions this --journal file has not marked done, without calling the LLM, then exit")
+	fs.StringVar(&c.Compare, "compare", c.Compare, "Print how this run's decisions differ, task by task, from those in this earlier --report file")
+	fs.StringVar(&c.Replay, "replay", c.Replay, "With --llm-backend replay, answer prompts from the artifacts --explain wrote to this directory")
+	fs.BoolVar(&c.Deterministic, "deterministic", c.Deterministic, "Process tasks one at a time in ID order, with a fixed seed and the clock pinned to the context date, so runs are reproducible")
+	fs.StringVar(&c.PrintPrompt, "print-prompt", c.PrintPrompt, "Print the LLM prompt for the task with this ID and exit, without calling the LLM")
+	fs.StringVar(&c.Scorer, "scorer", c.Scorer, "How the final inertia score is settled: llm (the LLM's score), deterministic (the computed score) or hybrid (the LLM's within --score-tolerance, else computed)")
+	fs.IntVar(&c.MaxMutations, "max-mutations", c.MaxMutations, "Execute at most this many non-skip actions per run, keeping the highest-scoring (0 for no cap)")
+	fs.BoolVar(&c.PromptPeople, "prompt-people", c.PromptPeople, "List the people a task mentions, with their context, in its prompt (--prompt-people=false to leave them out)")
+	fs.BoolVar(&c.Anonymize, "anonymize", c.Anonymize, "Replace the context's people with placeholders (Person A, ...) in prompts and restore them in the LLM's answer")
+	fs.StringVar(&c.Examples, "examples", c.Examples, "JSON file of example task/decision pairs to include in every prompt")
+	fs.StringVar(&c.LLMBackend, "llm-backend", c.LLMBackend, "How to reach the LLM: command (openclaw chat), http, or replay (recorded --explain responses)")
+	fs.StringVar(&c.LLMURL, "llm-url", c.LLMURL, "Base URL of an OpenAI-compatible server for --llm-backend http")
+	fs.StringVar(&c.LLMModel, "llm-model", c.LLMModel, "Model name to request from --llm-url")
+	fs.BoolVar(&c.FailFastOnParseErrors, "fail-fast-on-parse-errors", c.FailFastOnParseErrors, "Abort on the first unparseable LLM response and print it, instead of skipping the task")
+	fs.BoolVar(&c.TrustLLMScore, "trust-llm-score", c.TrustLLMScore, "Keep the LLM's inertia score even when it diverges from the computed one")
+	fs.StringVar((*string)(&c.PriorityScale), "priority-scale", string(c.PriorityScale), "Which end of 1-4 is urgent in task priorities: descending (1 = urgent, like td) or ascending (4 = urgent, like the Todoist API)")
+	fs.IntVar(&c.DecomposeAgeDays, "decompose-age-days", c.DecomposeAgeDays, "Only decompose tasks older than this many days")
+	fs.IntVar(&c.IceBoxAgeDays, "icebox-age-days", c.IceBoxAgeDays, "Only ice-box tasks older than this many days")
+	fs.Float64Var(&c.IceBoxMaxWeight, "icebox-max-weight", c.IceBoxMaxWeight, "In icebox mode, sweep old tasks whose historical weight is below this")
+	fs.IntVar(&c.HalfLifeDays, "half-life-days", c.HalfLifeDays, "Task age in days at which historical weight decays to half (0 disables decay)")
+	fs.StringVar(&c.WeightAggregator, "weight-aggregator", c.WeightAggregator, "How matched concepts combine into historical weight: max or boosted")
+	fs.IntVar(&c.MaxContextEntities, "max-context-entities", c.MaxContextEntities, "List at most this many related concepts and projects in each prompt, longest span first (0 = all)")
+	fs.Float64Var(&c.DuplicateSimilarity, "duplicate-similarity", c.DuplicateSimilarity, "Content similarity (0-1) at which tasks are reported as duplicates")
+	fs.BoolVar(&c.MergeDuplicates, "merge-duplicates", c.MergeDuplicates, "Keep the oldest task of each duplicate cluster and ice-box the rest")
+	fs.BoolVar(&c.MatchLabels, "match-labels", c.MatchLabels, "Match task labels against the gazetteer as well as content (--match-labels=false to disable)")
+	fs.BoolVar(&c.NormalizeMatching, "normalize-matching", c.NormalizeMatching, "Split camelCase and strip punctuation and emoji from task text and entity names before matching")
+	fs.Float64Var(&c.ScoreTolerance, "score-tolerance", c.ScoreTolerance, "How far the LLM's inertia score may stray from the computed one")
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gavmor/inertia-engine/internal/runner"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Configuration", func() {
+	writeConfig := func(name, body string) string {
+		path := filepath.Join(GinkgoT().TempDir(), name)
+		Expect(os.WriteFile(path, []byte(body), 0644)).To(Succeed())
+		return path
+	}
+
+	It("should fall back to built-in defaults", func() {
+		cfg, err := ParseFlags(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Concurrency).To(Equal(10))
+		Expect(cfg.Weights).To(Equal(Weights{Historical: 0.4, State: 0.3, Environment: 0.3, Intention: 0.2}))
+	})
+
+	It("should let the config file override defaults", func() {
+		path := writeConfig("inertia.json", `{"concurrency": 4, "grace_period": "5s", "weights": {"historical": 0.6, "state": 0.2, "environment": 0.2}}`)
+
+		cfg, err := ParseFlags([]string{"--config", path})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Concurrency).To(Equal(4))
+		Expect(time.Duration(cfg.GracePeriod)).To(Equal(5 * time.Second))
+		Expect(cfg.Weights.Historical).To(Equal(0.6))
+		Expect(cfg.IceBoxName).To(Equal("Ice Box"))
+	})
+
+	It("should pass per-command environment overrides to the commands they name", func() {
+		path := writeConfig("inertia.yaml", "env:\n  sh:\n    TODOIST_API_TOKEN: secret\n    MODEL: small\n")
+
+		cfg, err := ParseFlags([]string{"--config", path})
+		Expect(err).NotTo(HaveOccurred())
+		env := cfg.CommandEnv()
+		Expect(env).To(Equal(map[string][]string{"sh": {"MODEL=small", "TODOIST_API_TOKEN=secret"}}))
+
+		r := &runner.RealRunner{Env: env}
+		out, err := r.RunWithStdin("", "sh", "-c", `printf '%s %s' "$TODOIST_API_TOKEN" "$MODEL"`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal("secret small"))
+	})
+
+	It("should let flags override the config file", func() {
+		path := writeConfig("inertia.yaml", "concurrency: 4\ndry_run: true\n")
+
+		cfg, err := ParseFlags([]string{"--concurrency", "20", "--config", path})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Concurrency).To(Equal(20))
+		Expect(cfg.DryRun).To(BeTrue())
+	})
+
+	It("should accept day-suffixed durations from flags and files", func() {
+		path := writeConfig("inertia.json", `{"min_age": "14d"}`)
+
+		cfg, err := ParseFlags([]string{"--config", path})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(time.Duration(cfg.MinAge)).To(Equal(14 * 24 * time.Hour))
+
+		cfg, err = ParseFlags([]string{"--min-age", "720h"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(time.Duration(cfg.MinAge)).To(Equal(30 * 24 * time.Hour))
+	})
+
+	It("should report a malformed config file", func() {
+		path := writeConfig("inertia.json", `{"concurrency": "lots"}`)
+
+		_, err := LoadConfig(path)
+		Expect(err).To(MatchError(ContainSubstring("unmarshal config")))
+	})
+
+	It("should state the configured weights in the prompt", func() {
+		Settings.Weights = Weights{Historical: 0.5, State: 0.25, Environment: 0.25}
+		prompt := BuildDecisionPrompt(TaskContext{Task: Task{Content: "Anything"}})
+		Expect(prompt).To(ContainSubstring("historical_weight * 0.5 + state_alignment * 0.25 + environment * 0.25"))
+	})
+
+	It("should state the configured action age thresholds in the prompt", func() {
+		cfg, err := ParseFlags([]string{"--decompose-age-days", "21", "--icebox-age-days", "90"})
+		Expect(err).NotTo(HaveOccurred())
+		Settings = cfg
+
+		prompt := BuildDecisionPrompt(TaskContext{Task: Task{Content: "Anything"}})
+		Expect(prompt).To(ContainSubstring("break into subtasks (if >21 days old and stale)"))
+		Expect(prompt).To(ContainSubstring("move to ice-box (if >90 days old"))
+	})
+})
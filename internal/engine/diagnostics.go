@@ -0,0 +1,89 @@
+package engine
+
+import "sort"
+
+// maxUnmatchedTerms caps UnmatchedTaskTerms to the most frequent terms.
+const maxUnmatchedTerms = 50
+
+// EntityRef names a gazetteer entity and which list it is in.
+type EntityRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// TermCount is a word and how many tasks used it.
+type TermCount struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// MatchDiagnostics helps tune the gazetteer. UnmatchedEntities are people,
+// projects and concepts no task matched this run; UnmatchedTaskTerms are
+// the most common words of tasks that matched no entity at all, the likely
+// gaps.
+type MatchDiagnostics struct {
+	UnmatchedEntities  []EntityRef `json:"unmatched_entities"`
+	UnmatchedTaskTerms []TermCount `json:"unmatched_task_terms"`
+}
+
+type entityGroup struct {
+	kind     string
+	entities []Entity
+}
+
+func entityGroups(people, projects, concepts []Entity) []entityGroup {
+	return []entityGroup{{"person", people}, {"project", projects}, {"concept", concepts}}
+}
+
+// BuildMatchDiagnostics compares the matches ContextualizeTask recorded in
+// taskCtxs against the whole gazetteer.
+func BuildMatchDiagnostics(taskCtxs []TaskContext, gazetteer Gazetteer) MatchDiagnostics {
+	matched := make(map[EntityRef]bool)
+	counts := make(map[string]int)
+	for _, tc := range taskCtxs {
+		for _, group := range entityGroups(tc.RelatedPeople, tc.RelatedProjects, tc.RelatedConcepts) {
+			for _, e := range group.entities {
+				matched[EntityRef{group.kind, e.Name}] = true
+			}
+		}
+		if len(tc.RelatedPeople)+len(tc.RelatedProjects)+len(tc.RelatedConcepts) > 0 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, w := range contentWords(tc.Task.Content + " " + tc.Task.Description) {
+			if !seen[w] {
+				seen[w] = true
+				counts[w]++
+			}
+		}
+	}
+
+	var d MatchDiagnostics
+	for _, group := range entityGroups(gazetteer.People, gazetteer.Projects, gazetteer.Concepts) {
+		for _, e := range group.entities {
+			if ref := (EntityRef{group.kind, e.Name}); !matched[ref] {
+				d.UnmatchedEntities = append(d.UnmatchedEntities, ref)
+			}
+			// Words already naming an entity aren't gaps in the gazetteer.
+			for _, name := range e.names() {
+				for _, w := range contentWords(name) {
+					delete(counts, w)
+				}
+			}
+		}
+	}
+	for term, count := range counts {
+		d.UnmatchedTaskTerms = append(d.UnmatchedTaskTerms, TermCount{term, count})
+	}
+	sort.Slice(d.UnmatchedTaskTerms, func(i, j int) bool {
+		a, b := d.UnmatchedTaskTerms[i], d.UnmatchedTaskTerms[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Term < b.Term
+	})
+	if len(d.UnmatchedTaskTerms) > maxUnmatchedTerms {
+		d.UnmatchedTaskTerms = d.UnmatchedTaskTerms[:maxUnmatchedTerms]
+	}
+	return d
+}
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Match Diagnostics", func() {
+	inertiaCtx := &InertiaContext{Gazetteer: Gazetteer{
+		People:   []Entity{{Name: "Dana"}},
+		Projects: []Entity{{Name: "Home-Lab"}},
+		Concepts: []Entity{{Name: "Journaling"}, {Name: "Woodworking"}},
+	}}
+
+	It("should list entities no task matched and the words of unmatched tasks", func() {
+		var ctxs []TaskContext
+		for _, t := range []Task{
+			{ID: "1", Content: "Call Dana about journaling"},
+			{ID: "2", Content: "Renew passport"},
+			{ID: "3", Content: "Renew car insurance"},
+		} {
+			ctxs = append(ctxs, ContextualizeTask(t, inertiaCtx))
+		}
+
+		d := BuildMatchDiagnostics(ctxs, inertiaCtx.Gazetteer)
+		Expect(d.UnmatchedEntities).To(Equal([]EntityRef{{"project", "Home-Lab"}, {"concept", "Woodworking"}}))
+		Expect(d.UnmatchedTaskTerms).To(Equal([]TermCount{{"renew", 2}, {"car", 1}, {"insurance", 1}, {"passport", 1}}))
+	})
+
+	It("should not report words that already name an entity as gaps", func() {
+		ctxs := []TaskContext{{Task: Task{ID: "1", Content: "Sand woodworking bench"}}}
+		d := BuildMatchDiagnostics(ctxs, inertiaCtx.Gazetteer)
+		Expect(d.UnmatchedTaskTerms).To(Equal([]TermCount{{"bench", 1}, {"sand", 1}}))
+	})
+})
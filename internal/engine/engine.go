@@ -1,15 +1,29 @@
 package engine
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"math/rand/v2"
 	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gavmor/inertia-engine/internal/runner"
+	"golang.org/x/sync/errgroup"
 )
 
 // Global variables for mocking in tests
@@ -18,6 +32,41 @@ var (
 	NowFunc                            = time.Now
 )
 
+// Backend answers decision prompts; main swaps it per --llm-backend.
+var Backend = defaultBackend()
+
+// Log receives all engine output; main swaps it per --log-level/--log-format.
+var Log Logger = &slogLogger{l: slog.Default()}
+
+// FetchBackoff is the delay before the first fetch retry; each further
+// retry doubles it.
+var FetchBackoff = time.Second
+
+// LLMRetryBackoff is the delay before the first retry of a failed LLM call;
+// each further retry doubles it.
+var LLMRetryBackoff = 500 * time.Millisecond
+
+// Cache short-circuits LLM calls for unchanged tasks when set.
+var Cache *DecisionCache
+
+// NewTraceID generates the ID that ties a task's log lines, explain
+// artifact and decision together; tests swap it for a predictable one.
+var NewTraceID = func() string {
+	return fmt.Sprintf("%016x", rand.Uint64())
+}
+
+// traceIDFor is the trace ID for processing task: NewTraceID's, or under
+// Settings.Deterministic one derived from the task ID, so repeated runs
+// produce identical decisions and explain file names.
+func traceIDFor(task Task) string {
+	if !Settings.Deterministic {
+		return NewTraceID()
+	}
+	h := fnv.New64a()
+	h.Write([]byte(task.ID))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
 type InertiaContext struct {
 	Date       string     `json:"date"`
 	Gazetteer  Gazetteer  `json:"gazetteer"`
@@ -36,12 +85,33 @@ type Entity struct {
 	Name             string          `json:"name"`
 	Context          string          `json:"context"`
 	Sources          []string        `json:"sources"`
+	Aliases          []string        `json:"aliases,omitempty"`
 	SpanYears        json.RawMessage `json:"span_years,omitempty"`
 	Status           string          `json:"status,omitempty"`
 	Note             string          `json:"note,omitempty"`
 	EmotionalValence string          `json:"emotional_valence,omitempty"`
 }
 
+// names is the entity's name followed by its non-blank aliases: the
+// spellings a task may use to mention it.
+func (e Entity) names() []string {
+	names := []string{e.Name}
+	for _, alias := range e.Aliases {
+		if strings.TrimSpace(alias) != "" {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+// spanPattern matches the informal spans diaries produce: "10", "~8",
+// "10-15", "12+ years". The second group is the upper end of a range.
+var spanPattern = regexp.MustCompile(`^~?\s*(\d+(?:\.\d+)?)(?:\s*[-–]\s*(\d+(?:\.\d+)?))?`)
+
+// GetSpanYears reads span_years as a number, a numeric string or a
+// {"start": 2008, "end": 2024} object of years. String ranges resolve to
+// their midpoint; an object without an end runs to the current year, and
+// its span is the absolute difference. Anything unparseable counts as 0.
 func (e *Entity) GetSpanYears() float64 {
 	if len(e.SpanYears) == 0 {
 		return 0
@@ -50,9 +120,37 @@ func (e *Entity) GetSpanYears() float64 {
 	if err := json.Unmarshal(e.SpanYears, &num); err == nil {
 		return num
 	}
+	var str string
+	if err := json.Unmarshal(e.SpanYears, &str); err == nil {
+		return parseSpanString(str)
+	}
+	var years struct {
+		Start *float64 `json:"start"`
+		End   *float64 `json:"end"`
+	}
+	if err := json.Unmarshal(e.SpanYears, &years); err == nil && years.Start != nil {
+		end := float64(NowFunc().Year())
+		if years.End != nil {
+			end = *years.End
+		}
+		return math.Abs(end - *years.Start)
+	}
 	return 0
 }
 
+func parseSpanString(s string) float64 {
+	m := spanPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0
+	}
+	low, _ := strconv.ParseFloat(m[1], 64)
+	if m[2] == "" {
+		return low
+	}
+	high, _ := strconv.ParseFloat(m[2], 64)
+	return (low + high) / 2
+}
+
 type State struct {
 	Energy         string `json:"energy"`
 	Mood           string `json:"mood"`
@@ -75,20 +173,63 @@ type Task struct {
 	UpdatedAt   time.Time `json:"updatedAt"`
 	Labels      []string  `json:"labels"`
 	ProjectID   string    `json:"projectId"`
+	// Due is the task's due date as YYYY-MM-DD, empty when it has none.
+	Due string `json:"due,omitempty"`
+	// SourceID is the Binary of the source the task was fetched from when
+	// several are merged; see MultiSource.
+	SourceID string `json:"sourceId,omitempty"`
 }
 
+// TasksResponse is one page of `td task list --json`. NextCursor is empty on
+// the last page.
 type TasksResponse struct {
-	Results []Task `json:"results"`
+	Results    []Task `json:"results"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type Decision struct {
-	TaskID       string
-	Action       string
-	Priority     *int
-	NewContent   *string
-	Subtasks     []string
-	Reasoning    string
-	InertiaScore float64
+	TaskID       string   `json:"task_id"`
+	Action       string   `json:"action"`
+	Priority     *int     `json:"priority,omitempty"`
+	NewContent   *string  `json:"new_content,omitempty"`
+	Subtasks     []string `json:"subtasks,omitempty"`
+	Due          *string  `json:"due,omitempty"`
+	Reasoning    string   `json:"reasoning"`
+	InertiaScore float64  `json:"inertia_score"`
+
+	// CurrentPriority and CurrentContent are the task's values when it was
+	// fetched, so execution can skip no-op updates and record undo entries.
+	// Zero values mean unknown.
+	CurrentPriority int    `json:"current_priority,omitempty"`
+	CurrentContent  string `json:"current_content,omitempty"`
+	AgeDays         int    `json:"age_days,omitempty"`
+	// CurrentLabels are the task's labels when fetched, inherited by
+	// decompose's subtasks.
+	CurrentLabels []string `json:"current_labels,omitempty"`
+	// CurrentProjectID is the task's project when fetched, where undo moves
+	// an ice-boxed task back to.
+	CurrentProjectID string `json:"current_project_id,omitempty"`
+	// CurrentDue is the task's due date when fetched, empty when it had
+	// none, so undoing a defer can restore or clear it.
+	CurrentDue string `json:"current_due,omitempty"`
+	// SourceID is the task's SourceID, so mutations replayed without a
+	// fetch, as by --resume, reach the right source.
+	SourceID string `json:"source_id,omitempty"`
+
+	// ScoreBreakdown is the computed score's audit trail, attached whatever
+	// score the LLM gave.
+	ScoreBreakdown *ScoreBreakdown `json:"score_breakdown,omitempty"`
+
+	// TraceID matches the decision to its log lines and explain artifact.
+	TraceID string `json:"trace_id,omitempty"`
+
+	// Actions lists every action when the LLM chose more than one, in the
+	// order they are applied; Action and its fields then mirror the first.
+	Actions []SubAction `json:"actions,omitempty"`
+
+	// LLMError is why the LLM gave no usable answer, when this is the
+	// fallback skip.
+	LLMError string `json:"llm_error,omitempty"`
 }
 
 type TaskContext struct {
@@ -98,31 +239,166 @@ type TaskContext struct {
 	RelatedConcepts  []Entity
 	State            State
 	AgeDays          int
+	AgeUnknown       bool
 	HistoricalWeight float64
+	Intentions       Intentions
+
+	EnvironmentAlignment float64
+	IntentionAlignment   float64
+
+	// LLMScore is the inertia score the LLM gave, once it has answered.
+	LLMScore *float64
+	// TraceID is the ID of the ProcessTask call building this context.
+	TraceID string
 }
 
+// ErrEmptyContext is returned when the context input has no content at all,
+// typically because nothing was piped to --context -.
+var ErrEmptyContext = errors.New("context input is empty")
+
+// LoadContext reads the context file at path, or stdin when path is "-".
+// Any failure is a *ContextLoadError.
 func LoadContext(path string) (*InertiaContext, error) {
-	data, err := os.ReadFile(path)
+	ctx, err := loadContext(path)
+	if err != nil {
+		return nil, &ContextLoadError{Path: path, Err: err}
+	}
+	return ctx, nil
+}
+
+func loadContext(path string) (*InertiaContext, error) {
+	if path == "-" {
+		return LoadContextFrom(os.Stdin)
+	}
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
+	defer f.Close()
+	return LoadContextFrom(f)
+}
+
+// LoadContextFrom parses and validates a context document read from r.
+func LoadContextFrom(r io.Reader) (*InertiaContext, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read context: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, ErrEmptyContext
+	}
+	if problems := checkContextTypes(data); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
 	var ctx InertiaContext
 	if err := json.Unmarshal(data, &ctx); err != nil {
 		return nil, fmt.Errorf("unmarshal: %w", err)
 	}
+	if err := ValidateContext(&ctx); err != nil {
+		return nil, err
+	}
 	return &ctx, nil
 }
 
+// FetchAllTasks reads every task from Source, following pagination where
+// the source has it. A transient failure is retried up to
+// Settings.FetchRetries times with doubling backoff. Any final failure is a
+// *TaskFetchError.
 func FetchAllTasks() ([]Task, error) {
-	output, err := CommandRunner.Output("td", "task", "list", "--json", "--full")
+	tasks, err := Source.FetchTasks()
+	for attempt := 0; isTransientFetchError(err) && attempt < Settings.FetchRetries; attempt++ {
+		delay := FetchBackoff << attempt
+		Log.Warn("Fetching tasks from %s failed, retrying in %s: %v", Source.Binary(), delay, err)
+		time.Sleep(delay)
+		tasks, err = Source.FetchTasks()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("td command: %w", err)
+		return nil, &TaskFetchError{Source: Source.Binary(), Err: err}
+	}
+	return tasks, nil
+}
+
+// isTransientFetchError reports whether fetching again might succeed. A
+// missing binary, rejected credentials and output that isn't JSON won't
+// change on retry; a non-zero exit or dropped connection may.
+func isTransientFetchError(err error) bool {
+	if err == nil || isFatalRunnerError(err) {
+		return false
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+// ResolveProjectID looks up a project's ID by case-insensitive name.
+func ResolveProjectID(name string) (string, error) {
+	return Source.ResolveProjectID(name)
+}
+
+// ExcludeIceBoxed drops tasks already sitting in the ice-box project so a
+// run doesn't reconsider them. An empty project ID keeps everything.
+func ExcludeIceBoxed(tasks []Task, iceBoxProjectID string) []Task {
+	if iceBoxProjectID == "" {
+		return tasks
+	}
+	var kept []Task
+	for _, task := range tasks {
+		if task.ProjectID != localProjectID(iceBoxProjectID, task.SourceID) {
+			kept = append(kept, task)
+		}
 	}
-	var resp TasksResponse
-	if err := json.Unmarshal(output, &resp); err != nil {
-		return nil, fmt.Errorf("unmarshal tasks: %w", err)
+	return kept
+}
+
+// ExcludeByLabels drops tasks carrying any of labels, compared
+// case-insensitively. No labels keeps everything.
+func ExcludeByLabels(tasks []Task, labels []string) []Task {
+	if len(labels) == 0 {
+		return tasks
 	}
-	return resp.Results, nil
+	var kept []Task
+	for _, task := range tasks {
+		excluded := false
+		for _, l := range task.Labels {
+			for _, ex := range labels {
+				if strings.EqualFold(l, ex) {
+					excluded = true
+				}
+			}
+		}
+		if !excluded {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+// FilterByAge keeps tasks added at least minAge before now. Tasks with no
+// AddedAt are dropped rather than treated as infinitely old.
+func FilterByAge(tasks []Task, minAge time.Duration, now time.Time) []Task {
+	var kept []Task
+	for _, task := range tasks {
+		if task.AddedAt.IsZero() {
+			continue
+		}
+		if now.Sub(task.AddedAt) >= minAge {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+// CheckMinTasks guards against acting on a fetch that came back short, as
+// after an auth hiccup: fewer than min leaf tasks is an error, except that
+// allowEmpty lets a genuinely empty list through.
+func CheckMinTasks(count, min int, allowEmpty bool) error {
+	if count >= min || (count == 0 && allowEmpty) {
+		return nil
+	}
+	if count == 0 {
+		return fmt.Errorf("no leaf tasks fetched (--min-tasks %d); pass --allow-empty if the list really is empty", min)
+	}
+	return fmt.Errorf("only %d leaf tasks fetched, fewer than --min-tasks %d; the task source may have returned a partial list", count, min)
 }
 
 func FilterLeafNodes(tasks []Task) []Task {
@@ -132,6 +408,15 @@ func FilterLeafNodes(tasks []Task) []Task {
 			parentIDs[*task.ParentID] = true
 		}
 	}
+	if cyclic := DetectParentCycles(tasks); len(cyclic) > 0 {
+		Log.Warn("Tasks with circular parent references, treating as non-leaf: %v", cyclic)
+		for _, id := range cyclic {
+			parentIDs[id] = true
+		}
+	}
+	if dangling := DanglingParents(tasks); len(dangling) > 0 {
+		Log.Warn("Tasks whose parent was not fetched: %v", dangling)
+	}
 	var leafTasks []Task
 	for _, task := range tasks {
 		if !parentIDs[task.ID] {
@@ -141,72 +426,507 @@ func FilterLeafNodes(tasks []Task) []Task {
 	return leafTasks
 }
 
-func ProcessTasksParallel(tasks []Task, context *InertiaContext, maxConcurrency int) []Decision {
-	results := make(chan Decision, len(tasks))
-	sem := make(chan struct{}, maxConcurrency)
-	var wg sync.WaitGroup
+// SampleTasks picks n tasks at random, reproducibly for a given seed, and
+// returns them in their original order. It returns every task when n is not
+// smaller than len(tasks).
+func SampleTasks(tasks []Task, n int, seed int64) []Task {
+	if n >= len(tasks) {
+		return tasks
+	}
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	picked := rng.Perm(len(tasks))[:n]
+	sort.Ints(picked)
+	sample := make([]Task, n)
+	for i, idx := range picked {
+		sample[i] = tasks[idx]
+	}
+	return sample
+}
 
+// DetectParentCycles returns the sorted IDs of tasks whose parent chain loops
+// back on itself.
+func DetectParentCycles(tasks []Task) []string {
+	parentOf := make(map[string]string, len(tasks))
 	for _, task := range tasks {
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(t Task) {
-			defer wg.Done()
-			defer func() { <-sem }()
-			decision := ProcessTask(t, context)
-			results <- decision
-		}(task)
+		if task.ParentID != nil {
+			parentOf[task.ID] = *task.ParentID
+		}
 	}
 
+	inCycle := make(map[string]bool)
+	done := make(map[string]bool)
+	for _, task := range tasks {
+		onPath := make(map[string]int)
+		var path []string
+		for id := task.ID; id != "" && !done[id]; id = parentOf[id] {
+			if start, seen := onPath[id]; seen {
+				for _, c := range path[start:] {
+					inCycle[c] = true
+				}
+				break
+			}
+			onPath[id] = len(path)
+			path = append(path, id)
+		}
+		for _, id := range path {
+			done[id] = true
+		}
+	}
+
+	ids := make([]string, 0, len(inCycle))
+	for id := range inCycle {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DanglingParents returns the IDs of tasks whose ParentID names a task that
+// is not in the list.
+func DanglingParents(tasks []Task) []string {
+	known := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		known[task.ID] = true
+	}
+	var ids []string
+	for _, task := range tasks {
+		if task.ParentID != nil && !known[*task.ParentID] {
+			ids = append(ids, task.ID)
+		}
+	}
+	return ids
+}
+
+// ProgressFunc is called once per processed task with the running count.
+type ProgressFunc func(completed, total int)
+
+type processOptions struct {
+	progress    ProgressFunc
+	gracePeriod time.Duration
+	limiter     *RateLimiter
+	retries     *RetryBudget
+	ordered     bool
+	adaptive    *AdaptiveLimiter
+}
+
+// ProcessOption tweaks ProcessTasksParallel without changing its signature.
+type ProcessOption func(*processOptions)
+
+func WithProgress(fn ProgressFunc) ProcessOption {
+	return func(o *processOptions) {
+		o.progress = fn
+	}
+}
+
+// WithGracePeriod bounds how long in-flight tasks may keep running once the
+// context is cancelled. Zero waits for them indefinitely.
+func WithGracePeriod(d time.Duration) ProcessOption {
+	return func(o *processOptions) {
+		o.gracePeriod = d
+	}
+}
+
+// WithRateLimiter spaces out LLM calls on top of the concurrency bound.
+func WithRateLimiter(l *RateLimiter) ProcessOption {
+	return func(o *processOptions) {
+		o.limiter = l
+	}
+}
+
+// WithRetryBudget lets failed LLM calls be retried, drawing on b.
+func WithRetryBudget(b *RetryBudget) ProcessOption {
+	return func(o *processOptions) {
+		o.retries = b
+	}
+}
+
+// WithOrderedResults returns decisions in the order of the input tasks rather
+// than the order they completed in, so reports from two runs diff cleanly.
+// Tasks left unprocessed are simply absent.
+func WithOrderedResults() ProcessOption {
+	return func(o *processOptions) {
+		o.ordered = true
+	}
+}
+
+// WithAdaptiveConcurrency lets l tune how many tasks run at once, in place
+// of the fixed maxConcurrency.
+func WithAdaptiveConcurrency(l *AdaptiveLimiter) ProcessOption {
+	return func(o *processOptions) {
+		o.adaptive = l
+	}
+}
+
+// ProcessTasksParallel is ProcessTasksParallelContext without cancellation.
+// A fatal runner error is logged and ends processing early.
+func ProcessTasksParallel(tasks []Task, inertiaCtx *InertiaContext, maxConcurrency int, opts ...ProcessOption) []Decision {
+	decisions, err := ProcessTasksParallelContext(context.Background(), tasks, inertiaCtx, maxConcurrency, opts...)
+	if err != nil {
+		Log.Error("Processing aborted: %v", err)
+	}
+	return decisions
+}
+
+// SortTasksByID returns a copy of tasks ordered by ID.
+func SortTasksByID(tasks []Task) []Task {
+	sorted := make([]Task, len(tasks))
+	copy(sorted, tasks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// ProcessTasksParallelContext stops dispatching new tasks once ctx is
+// cancelled and returns the decisions for tasks that were already in flight.
+// With Settings.Deterministic it processes them one at a time in ID order.
+// Per-task failures become skip decisions; a fatal runner error (see
+// isFatalRunnerError), or a *ParseError when Settings.FailFastOnParseErrors
+// is set, stops the run and is returned.
+func ProcessTasksParallelContext(ctx context.Context, tasks []Task, inertiaCtx *InertiaContext, maxConcurrency int, opts ...ProcessOption) ([]Decision, error) {
+	tasks, maxConcurrency, options := prepareProcessing(tasks, maxConcurrency, opts)
+	abandon := make(chan struct{})
+	defer close(abandon)
+	results, wait := dispatchTasks(ctx, tasks, inertiaCtx, maxConcurrency, options, abandon)
+
+	// Each decision lands in its input slot as well as in completion order,
+	// so the ordered view needs no sorting.
+	var decisions []Decision
+	byIndex := make([]*Decision, len(tasks))
+	collected := func() []Decision {
+		if !options.ordered {
+			return decisions
+		}
+		var ordered []Decision
+		for _, d := range byIndex {
+			if d != nil {
+				ordered = append(ordered, *d)
+			}
+		}
+		return ordered
+	}
+	var graceExpired <-chan time.Time
+	done := ctx.Done()
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return collected(), wait()
+			}
+			decisions = append(decisions, r.decision)
+			byIndex[r.index] = &r.decision
+			if options.progress != nil {
+				options.progress(len(decisions), len(tasks))
+			}
+		case <-done:
+			done = nil
+			if options.gracePeriod > 0 {
+				graceExpired = time.After(options.gracePeriod)
+			}
+		case <-graceExpired:
+			Log.Warn("Grace period expired; abandoning in-flight tasks")
+			return collected(), nil
+		}
+	}
+}
+
+// StreamDecisions sends each task's decision on the returned channel as soon
+// as it is made, in completion order, and closes it after the last one.
+// Unlike ProcessTasksParallel it never holds more than about maxConcurrency
+// decisions: until the consumer takes one, no new task starts. A fatal
+// runner error is logged and closes the channel early. WithOrderedResults
+// and WithGracePeriod don't apply.
+func StreamDecisions(tasks []Task, inertiaCtx *InertiaContext, maxConcurrency int, opts ...ProcessOption) <-chan Decision {
+	tasks, maxConcurrency, options := prepareProcessing(tasks, maxConcurrency, opts)
+	results, wait := dispatchTasks(context.Background(), tasks, inertiaCtx, maxConcurrency, options, nil)
+	out := make(chan Decision)
+	go func() {
+		defer close(out)
+		completed := 0
+		for r := range results {
+			out <- r.decision
+			completed++
+			if options.progress != nil {
+				options.progress(completed, len(tasks))
+			}
+		}
+		if err := wait(); err != nil {
+			Log.Error("Processing aborted: %v", err)
+		}
+	}()
+	return out
+}
+
+// prepareProcessing applies opts, and Settings.Deterministic's ID order and
+// single worker.
+func prepareProcessing(tasks []Task, maxConcurrency int, opts []ProcessOption) ([]Task, int, *processOptions) {
+	var options processOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if Settings.Deterministic {
+		tasks = SortTasksByID(tasks)
+		maxConcurrency = 1
+		options.adaptive = nil
+	}
+	return tasks, maxConcurrency, &options
+}
+
+// indexedDecision is a decision and the position of its task in the input.
+type indexedDecision struct {
+	index    int
+	decision Decision
+}
+
+// dispatchTasks starts tasks in order, at most maxConcurrency at a time, and
+// sends their decisions on the returned channel as they complete. A worker
+// keeps its slot until its decision is taken, so a slow consumer holds back
+// new tasks instead of letting decisions pile up. The channel is closed once
+// dispatching is over; wait then returns the error that stopped it, if any.
+// Closing abandon frees workers whose consumer has gone away.
+func dispatchTasks(ctx context.Context, tasks []Task, inertiaCtx *InertiaContext, maxConcurrency int, options *processOptions, abandon <-chan struct{}) (<-chan indexedDecision, func() error) {
+	results := make(chan indexedDecision, maxConcurrency)
+	sem := make(chan struct{}, maxConcurrency)
+	acquire := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sem <- struct{}{}:
+			return nil
+		}
+	}
+	release := func() { <-sem }
+	if options.adaptive != nil {
+		acquire, release = options.adaptive.Acquire, options.adaptive.Release
+	}
+	g, gctx := errgroup.WithContext(ctx)
+
+	// Workers are started only once they have a slot, so tasks start in
+	// order and a large backlog costs no more than the tasks in flight.
+	g.Go(func() error {
+		for i, task := range tasks {
+			if acquire(gctx) != nil {
+				return nil
+			}
+			if gctx.Err() != nil {
+				release()
+				return nil
+			}
+			g.Go(func() error {
+				defer release()
+				decision, err := processTask(gctx, task, inertiaCtx, options)
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				select {
+				case results <- indexedDecision{i, decision}:
+				case <-abandon:
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	var groupErr error
 	go func() {
-		wg.Wait()
+		groupErr = g.Wait()
 		close(results)
 	}()
+	return results, func() error { return groupErr }
+}
 
-	var decisions []Decision
-	for decision := range results {
-		decisions = append(decisions, decision)
+func ProcessTask(task Task, inertiaCtx *InertiaContext) Decision {
+	decision, _ := processTask(context.Background(), task, inertiaCtx, &processOptions{})
+	return decision
+}
+
+// processTask returns an error only when the task should not be reported:
+// ctx was cancelled while waiting on the rate limiter, the runner failed
+// in a way that dooms every other task too, or the response was unparseable
+// under Settings.FailFastOnParseErrors.
+func processTask(ctx context.Context, task Task, inertiaCtx *InertiaContext, options *processOptions) (Decision, error) {
+	traceID := traceIDFor(task)
+	decision, err := decideTask(ctx, task, inertiaCtx, options, traceID)
+	decision.TraceID = traceID
+	decision = withFetchedValues(decision, task)
+	decision = DropDegenerateSubtasks(decision, task.Content)
+	breakdown := BuildScoreBreakdown(ContextualizeTask(task, inertiaCtx), Settings.Weights)
+	decision.ScoreBreakdown = &breakdown
+	return decision, err
+}
+
+// withFetchedValues copies the task's current values onto its decision.
+func withFetchedValues(decision Decision, task Task) Decision {
+	decision.CurrentPriority = task.Priority
+	decision.CurrentContent = task.Content
+	decision.AgeDays = taskAgeDays(task)
+	decision.CurrentLabels = task.Labels
+	decision.CurrentProjectID = task.ProjectID
+	decision.CurrentDue = task.Due
+	decision.SourceID = task.SourceID
+	return decision
+}
+
+func decideTask(ctx context.Context, task Task, inertiaCtx *InertiaContext, options *processOptions, traceID string) (Decision, error) {
+	if Cache != nil {
+		if decision, ok := Cache.Get(task, inertiaCtx.State); ok {
+			return decision, nil
+		}
 	}
-	return decisions
+	if Settings.RespectInlinePriority {
+		if priority, _ := ExtractInlinePriority(task.Content); priority != nil {
+			score, _ := ActiveScorer.Score(ContextualizeTask(task, inertiaCtx))
+			return Decision{
+				TaskID:       task.ID,
+				Action:       "reprioritize",
+				Priority:     priority,
+				Reasoning:    fmt.Sprintf("task content marks it %s", NormalizePriority(*priority, Settings.PriorityScale)),
+				InertiaScore: score,
+			}, nil
+		}
+	}
+	if options.limiter != nil {
+		if err := options.limiter.Wait(ctx); err != nil {
+			return Decision{}, err
+		}
+	}
+	taskCtx := ContextualizeTask(task, inertiaCtx)
+	taskCtx.TraceID = traceID
+	if Settings.Verbose {
+		Log.Info("Task %s matched %s [trace %s]", task.ID, DescribeMatches(taskCtx), traceID)
+	}
+	decision, exchange, err := requestDecision(ctx, taskCtx, options)
+	if Settings.Explain != "" {
+		if err := WriteExplain(Settings.Explain, exchange); err != nil {
+			Log.Warn("Failed to write explain artifact for task %s [trace %s]: %v", task.ID, traceID, err)
+		}
+	}
+	if isFatalRunnerError(err) {
+		return decision, err
+	}
+	var parseErr *ParseError
+	if Settings.FailFastOnParseErrors && errors.As(err, &parseErr) {
+		return decision, err
+	}
+	if err != nil {
+		llmFailures.Add(1)
+		decision.LLMError = err.Error()
+		return decision, nil
+	}
+	decision = ReconcileScore(decision, taskCtx)
+	if Cache != nil {
+		Cache.Put(task, inertiaCtx.State, decision)
+	}
+	return decision, nil
 }
 
-func ProcessTask(task Task, context *InertiaContext) Decision {
-	taskCtx := ContextualizeTask(task, context)
-	return CallAgentForDecision(taskCtx)
+// isFatalRunnerError reports whether err means no task can succeed this run:
+// the command is missing or not executable, or it rejected our credentials.
+// Anything else, such as a plain non-zero exit, only affects one task.
+func isFatalRunnerError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr := strings.ToLower(string(exitErr.Stderr))
+		for _, marker := range []string{"unauthorized", "authentication", "invalid token", "401"} {
+			if strings.Contains(stderr, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesConcept reports whether text mentions a concept. Single-word names
+// match as a substring. Multi-word names match on the full phrase, or when at
+// least minFraction of their words appear, so "machine learning" isn't
+// triggered by "learning" alone.
+func matchesConcept(text, name string, minFraction float64) bool {
+	phrase := strings.ToLower(name)
+	keywords := strings.Fields(phrase)
+	if len(keywords) == 0 {
+		return false
+	}
+	if strings.Contains(text, phrase) {
+		return true
+	}
+	if len(keywords) == 1 {
+		return false
+	}
+	found := 0
+	for _, kw := range keywords {
+		if strings.Contains(text, kw) {
+			found++
+		}
+	}
+	return float64(found)/float64(len(keywords)) >= minFraction
+}
+
+// taskAgeDays is how many whole days ago task was added. A task with no
+// AddedAt counts as 0 days old rather than as added in year 1, so an unknown
+// age never qualifies it for decompose or ice-box.
+func taskAgeDays(task Task) int {
+	if task.AddedAt.IsZero() {
+		return 0
+	}
+	return int(NowFunc().Sub(task.AddedAt).Hours() / 24)
 }
 
 func ContextualizeTask(task Task, context *InertiaContext) TaskContext {
-	taskText := strings.ToLower(task.Content + " " + task.Description)
+	taskText := task.Content + " " + task.Description
+	if Settings.MatchLabels && len(task.Labels) > 0 {
+		taskText += " " + strings.Join(task.Labels, " ")
+	}
+	matchKey := strings.ToLower
+	if Settings.NormalizeMatching {
+		matchKey = NormalizeForMatch
+	}
+	taskText = matchKey(taskText)
+	mentioned := func(e Entity) bool {
+		for _, name := range e.names() {
+			if strings.Contains(taskText, matchKey(name)) {
+				return true
+			}
+		}
+		return false
+	}
 	var relatedPeople []Entity
 	for _, person := range context.Gazetteer.People {
-		if strings.Contains(taskText, strings.ToLower(person.Name)) {
+		if mentioned(person) {
 			relatedPeople = append(relatedPeople, person)
 		}
 	}
 	var relatedProjects []Entity
 	for _, project := range context.Gazetteer.Projects {
-		if strings.Contains(taskText, strings.ToLower(project.Name)) {
+		if mentioned(project) {
 			relatedProjects = append(relatedProjects, project)
 		}
 	}
 	var relatedConcepts []Entity
 	for _, concept := range context.Gazetteer.Concepts {
-		keywords := strings.Split(strings.ToLower(concept.Name), " ")
-		for _, kw := range keywords {
-			if strings.Contains(taskText, kw) {
+		for _, name := range concept.names() {
+			if matchesConcept(taskText, matchKey(name), Settings.ConceptMatchFraction) {
 				relatedConcepts = append(relatedConcepts, concept)
 				break
 			}
 		}
 	}
 
-	ageDays := int(NowFunc().Sub(task.AddedAt).Hours() / 24)
-	var maxSpan float64
-	for _, concept := range relatedConcepts {
-		years := concept.GetSpanYears()
-		if years > maxSpan {
-			maxSpan = years
-		}
+	ageDays := taskAgeDays(task)
+	spans := make([]float64, len(relatedConcepts))
+	for i, concept := range relatedConcepts {
+		spans[i] = concept.GetSpanYears()
 	}
+	// Label bonuses don't fade with the task's age.
+	historical := DecayedWeight(Aggregator.Aggregate(spans), ageDays, Settings.HalfLifeDays) +
+		LabelWeightBonus(task.Labels, Settings.LabelWeightBonuses)
 
 	return TaskContext{
 		Task:             task,
@@ -215,132 +935,595 @@ func ContextualizeTask(task Task, context *InertiaContext) TaskContext {
 		RelatedConcepts:  relatedConcepts,
 		State:            context.State,
 		AgeDays:          ageDays,
-		HistoricalWeight: maxSpan,
+		AgeUnknown:       task.AddedAt.IsZero(),
+		HistoricalWeight: historical,
+		Intentions:       context.Intentions,
+
+		EnvironmentAlignment: ComputeEnvironmentAlignment(task, context.State.Environment),
+		IntentionAlignment:   ComputeIntentionAlignment(task, context.Intentions),
 	}
 }
 
-func CallAgentForDecision(taskCtx TaskContext) Decision {
-	prompt := BuildDecisionPrompt(taskCtx)
-	output, err := CommandRunner.RunWithStdin(prompt, "openclaw", "chat")
+// CallAgentForDecision asks the LLM to decide on one task, retrying a failed
+// call up to Settings.LLMRetries times while budget lasts. On failure it
+// returns the fallback skip decision together with an *LLMError.
+func CallAgentForDecision(taskCtx TaskContext, budget *RetryBudget) (Decision, error) {
+	decision, _, err := requestDecision(context.Background(), taskCtx, &processOptions{retries: budget})
+	return decision, err
+}
+
+// requestDecision asks the LLM for a decision and returns the raw exchange
+// alongside it. Retries back off and wait their turn on the rate limiter;
+// cancelling ctx stops them. On failure it still returns the fallback skip
+// decision, together with an *LLMError wrapping the cause.
+func requestDecision(ctx context.Context, taskCtx TaskContext, options *processOptions) (Decision, Exchange, error) {
+	budget := options.retries
+	exchange := Exchange{TaskID: taskCtx.Task.ID, TraceID: taskCtx.TraceID}
+	var names map[string]string
+	exchange.Prompt, names = outgoingPrompt(taskCtx)
+	var output string
+	var err error
+	for attempt := 0; ; attempt++ {
+		start := LLMLatency.Now()
+		output, err = Backend.Decide(exchange.Prompt)
+		elapsed := LLMLatency.Since(start)
+		if err == nil && strings.TrimSpace(output) == "" {
+			err = ErrEmptyOutput
+		}
+		if options.adaptive != nil {
+			options.adaptive.Observe(elapsed, err)
+		}
+		if err == nil || isFatalRunnerError(err) || attempt >= Settings.LLMRetries || !budget.Take() {
+			break
+		}
+		delay := LLMRetryBackoff << attempt
+		Log.Warn("LLM call failed for task %s [trace %s], retrying in %s (%d retries left this run): %v", taskCtx.Task.ID, taskCtx.TraceID, delay, budget.Remaining(), err)
+		if sleepContext(ctx, delay) != nil {
+			break
+		}
+		if options.limiter != nil && options.limiter.Wait(ctx) != nil {
+			break
+		}
+	}
+	exchange.Response = output
 	if err != nil {
-		log.Printf("LLM call failed for task %s: %v", taskCtx.Task.ID, err)
+		Log.Warn("LLM call failed for task %s [trace %s]: %v", taskCtx.Task.ID, taskCtx.TraceID, err)
+		reasoning := fmt.Sprintf("LLM call failed: %v", err)
+		if errors.Is(err, ErrEmptyOutput) {
+			reasoning = ErrEmptyOutput.Error()
+		}
 		return Decision{
 			TaskID:    taskCtx.Task.ID,
 			Action:    "skip",
-			Reasoning: fmt.Sprintf("LLM call failed: %v", err),
+			Reasoning: reasoning,
+		}, exchange, &LLMError{TaskID: taskCtx.Task.ID, Err: err}
+	}
+	decision, err := parseDecision(output, taskCtx.Task.ID)
+	if err != nil {
+		return decision, exchange, &LLMError{TaskID: taskCtx.Task.ID, Err: &ParseError{TaskID: taskCtx.Task.ID, Response: output, Err: err}}
+	}
+	if len(names) > 0 {
+		decision = deanonymizeDecision(decision, names)
+	}
+	return decision, exchange, nil
+}
+
+// outgoingPrompt is the prompt exactly as sent to the LLM, anonymized when
+// AnonymizePeople is set, with the placeholder mapping if so.
+func outgoingPrompt(taskCtx TaskContext) (string, map[string]string) {
+	prompt := BuildDecisionPrompt(taskCtx)
+	if len(AnonymizePeople) == 0 {
+		return prompt, nil
+	}
+	return Anonymize(prompt, AnonymizePeople)
+}
+
+// PromptForTask contextualizes the task with the given ID and returns the
+// prompt the LLM would be sent for it, for --print-prompt.
+func PromptForTask(tasks []Task, id string, inertiaCtx *InertiaContext) (string, error) {
+	for _, task := range tasks {
+		if task.ID == id {
+			prompt, _ := outgoingPrompt(ContextualizeTask(task, inertiaCtx))
+			return prompt, nil
+		}
+	}
+	return "", fmt.Errorf("task %s not found among %d fetched tasks", id, len(tasks))
+}
+
+// deanonymizeDecision restores real names wherever the LLM may have written
+// a placeholder, including text that will be written back to the task.
+func deanonymizeDecision(decision Decision, names map[string]string) Decision {
+	decision.Reasoning = Deanonymize(decision.Reasoning, names)
+	if decision.NewContent != nil {
+		content := Deanonymize(*decision.NewContent, names)
+		decision.NewContent = &content
+	}
+	if len(decision.Subtasks) > 0 {
+		subtasks := make([]string, len(decision.Subtasks))
+		for i, s := range decision.Subtasks {
+			subtasks[i] = Deanonymize(s, names)
+		}
+		decision.Subtasks = subtasks
+	}
+	if len(decision.Actions) > 0 {
+		actions := make([]SubAction, len(decision.Actions))
+		for i, single := range subDecisions(decision) {
+			single = deanonymizeDecision(single, names)
+			actions[i] = SubAction{Action: single.Action, Priority: single.Priority, NewContent: single.NewContent, Subtasks: single.Subtasks, Due: single.Due}
 		}
+		decision.Actions = actions
 	}
-	return ParseDecisionResponse(string(output), taskCtx.Task.ID)
+	return decision
+}
+
+// TopRelatedConcepts keeps the k entities with the longest span, breaking
+// ties by name so the prompt is stable across runs. A k of zero or less, or
+// at least len(concepts), keeps them all in their original order.
+func TopRelatedConcepts(concepts []Entity, k int) []Entity {
+	if k <= 0 || k >= len(concepts) {
+		return concepts
+	}
+	ranked := make([]Entity, len(concepts))
+	copy(ranked, concepts)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := ranked[i].GetSpanYears(), ranked[j].GetSpanYears()
+		if si != sj {
+			return si > sj
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked[:k]
+}
+
+// DescribeMatches lists the gazetteer entities a task matched, grouped by
+// type, for --verbose.
+func DescribeMatches(taskCtx TaskContext) string {
+	names := func(entities []Entity) string {
+		if len(entities) == 0 {
+			return "none"
+		}
+		list := make([]string, len(entities))
+		for i, e := range entities {
+			list[i] = e.Name
+		}
+		return strings.Join(list, ", ")
+	}
+	return fmt.Sprintf("people: %s; projects: %s; concepts: %s",
+		names(taskCtx.RelatedPeople), names(taskCtx.RelatedProjects), names(taskCtx.RelatedConcepts))
 }
 
 func BuildDecisionPrompt(taskCtx TaskContext) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Task: %s\n", taskCtx.Task.Content))
-	sb.WriteString(fmt.Sprintf("Created: %d days ago\n", taskCtx.AgeDays))
-	sb.WriteString(fmt.Sprintf("Current priority: p%d\n\n", taskCtx.Task.Priority))
+	if taskCtx.AgeUnknown {
+		sb.WriteString("Created: age unknown (no creation date), so don't decompose or ice-box it for being old\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("Created: %d days ago\n", taskCtx.AgeDays))
+	}
+	sb.WriteString(fmt.Sprintf("Current priority: %d (%s)\n\n", taskCtx.Task.Priority, Settings.PriorityScale.describe()))
+	if priority, _ := ExtractInlinePriority(taskCtx.Task.Content); priority != nil {
+		sb.WriteString(fmt.Sprintf("The task text itself marks it %s; weigh that when reprioritizing.\n\n", NormalizePriority(*priority, Settings.PriorityScale)))
+	}
+	if label, forced := labelOverride(taskCtx.Task, Settings.LabelOverrides); forced != "" && forced != "skip" {
+		sb.WriteString(fmt.Sprintf("This task is labelled %q: you must choose %q.\n\n", label, forced))
+	}
 	sb.WriteString("Current state:\n")
 	sb.WriteString(fmt.Sprintf("- Energy: %s\n", taskCtx.State.Energy))
 	sb.WriteString(fmt.Sprintf("- Mood: %s\n", taskCtx.State.Mood))
-	sb.WriteString(fmt.Sprintf("- Environment: %s\n\n", taskCtx.State.Environment))
+	sb.WriteString(fmt.Sprintf("- Environment: %s\n", taskCtx.State.Environment))
+	sb.WriteString(fmt.Sprintf("- Environment alignment for this task: %.0f/10\n", taskCtx.EnvironmentAlignment))
+	sb.WriteString(fmt.Sprintf("- Work volatility: %s\n\n", taskCtx.State.WorkVolatility))
+	if hint := VolatilityBias(taskCtx.State); hint != "" {
+		sb.WriteString(hint + "\n\n")
+	}
+
+	if in := taskCtx.Intentions; len(in.Explicit) > 0 || len(in.Implicit) > 0 {
+		sb.WriteString("Today's intentions:\n")
+		for _, i := range in.Explicit {
+			sb.WriteString(fmt.Sprintf("- %s (explicit)\n", i))
+		}
+		for _, i := range in.Implicit {
+			sb.WriteString(fmt.Sprintf("- %s (implicit)\n", i))
+		}
+		sb.WriteString(fmt.Sprintf("Intention alignment for this task: %.0f/10\n", taskCtx.IntentionAlignment))
+		if taskCtx.IntentionAlignment < neutralAlignment && taskCtx.AgeDays > Settings.IceBoxAgeDays {
+			sb.WriteString("This old task serves none of today's intentions: lean toward \"ice-box\".\n")
+		} else if taskCtx.IntentionAlignment >= 10 {
+			sb.WriteString("This task serves an explicit intention: lean toward acting on it rather than \"skip\" or \"ice-box\".\n")
+		}
+		sb.WriteString("\n")
+	}
 
 	if len(taskCtx.RelatedConcepts) > 0 {
 		sb.WriteString("Related concepts from diary history:\n")
-		for _, c := range taskCtx.RelatedConcepts {
+		concepts := TopRelatedConcepts(taskCtx.RelatedConcepts, Settings.MaxContextEntities)
+		for _, c := range concepts {
 			sb.WriteString(fmt.Sprintf("- %s (%.0f years): %s\n", c.Name, c.GetSpanYears(), c.Context))
 		}
+		if omitted := len(taskCtx.RelatedConcepts) - len(concepts); omitted > 0 {
+			sb.WriteString(fmt.Sprintf("- (%d more concepts omitted)\n", omitted))
+		}
+		sb.WriteString(fmt.Sprintf("Historical weight after age decay: %.1f (half-life %d days)\n", taskCtx.HistoricalWeight, Settings.HalfLifeDays))
 		sb.WriteString("\n")
 	}
 
 	if len(taskCtx.RelatedProjects) > 0 {
 		sb.WriteString("Related projects:\n")
-		for _, p := range taskCtx.RelatedProjects {
+		for _, p := range TopRelatedConcepts(taskCtx.RelatedProjects, Settings.MaxContextEntities) {
 			sb.WriteString(fmt.Sprintf("- %s: %s\n", p.Name, p.Context))
 		}
 		sb.WriteString("\n")
 	}
 
+	// With --anonymize, outgoingPrompt swaps these names for placeholders
+	// like every other mention of a person.
+	if Settings.PromptPeople && len(taskCtx.RelatedPeople) > 0 {
+		sb.WriteString("Related people:\n")
+		for _, p := range TopRelatedConcepts(taskCtx.RelatedPeople, Settings.MaxContextEntities) {
+			if p.EmotionalValence != "" {
+				sb.WriteString(fmt.Sprintf("- %s (%s): %s\n", p.Name, p.EmotionalValence, p.Context))
+			} else {
+				sb.WriteString(fmt.Sprintf("- %s: %s\n", p.Name, p.Context))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(RenderExamples(Examples))
+
 	sb.WriteString("Based on this context, decide ONE action for this task:\n")
 	sb.WriteString("1. \"skip\" - no action needed\n")
-	sb.WriteString("2. \"decompose\" - break into subtasks (if >14 days old and stale)\n")
-	sb.WriteString("3. \"ice-box\" - move to ice-box (if >30 days old and low historical alignment)\n")
+	sb.WriteString(fmt.Sprintf("2. \"decompose\" - break into subtasks (if >%d days old and stale)\n", Settings.DecomposeAgeDays))
+	sb.WriteString(fmt.Sprintf("3. \"ice-box\" - move to ice-box (if >%d days old and low historical alignment)\n", Settings.IceBoxAgeDays))
 	sb.WriteString("4. \"reprioritize\" - change priority based on inertia score\n")
-	sb.WriteString("5. \"recontextualize\" - rewrite task to be more atomic/specific\n\n")
+	sb.WriteString("5. \"recontextualize\" - rewrite task to be more atomic/specific\n")
+	sb.WriteString("6. \"defer\" - push the due date out (if still worth doing, just not now)\n\n")
 	sb.WriteString("Respond with JSON only:\n")
 	sb.WriteString("{\n")
-	sb.WriteString("  \"action\": \"skip|decompose|ice-box|reprioritize|recontextualize\",\n")
-	sb.WriteString("  \"priority\": 1-4 (if reprioritizing),\n")
+	sb.WriteString("  \"action\": \"skip|decompose|ice-box|reprioritize|recontextualize|defer\",\n")
+	sb.WriteString("  \"priority\": 1-4 on the same scale as the current priority, or \"none\" to clear it (if reprioritizing),\n")
 	sb.WriteString("  \"new_content\": \"...\" (if recontextualizing),\n")
 	sb.WriteString("  \"subtasks\": [\"...\", \"...\"], (if decomposing),\n")
+	sb.WriteString("  \"due\": \"+7d\" or \"YYYY-MM-DD\" (if deferring),\n")
 	sb.WriteString("  \"reasoning\": \"brief explanation\",\n")
-	sb.WriteString("  \"inertia_score\": 0-10 (historical_weight * 0.4 + state_alignment * 0.3 + environment * 0.3)\n")
-	sb.WriteString("}")
+	w := Settings.Weights
+	sb.WriteString(fmt.Sprintf("  \"inertia_score\": 0-10 (historical_weight * %g + state_alignment * %g + environment * %g)\n", w.Historical, w.State, w.Environment))
+	sb.WriteString("}\n")
+	sb.WriteString("If the task truly needs two changes, such as a rewrite and a new priority, replace \"action\" and its fields with a list: ")
+	sb.WriteString("\"actions\": [{\"action\": \"recontextualize\", \"new_content\": \"...\"}, {\"action\": \"reprioritize\", \"priority\": 2}]")
 	return sb.String()
 }
 
+// VolatilityBias returns a prompt hint steering decisions by work volatility:
+// an unstable week favors leaving non-urgent tasks alone over restructuring
+// them. It returns "" when volatility is unknown or moderate.
+func VolatilityBias(state State) string {
+	switch v := strings.ToLower(state.WorkVolatility); {
+	case strings.Contains(v, "high"):
+		return "Work volatility is high: for non-urgent tasks prefer \"skip\" or \"ice-box\" over \"decompose\", since plans are likely to change."
+	case strings.Contains(v, "low"):
+		return "Work volatility is low: the week is stable enough to \"decompose\" or \"recontextualize\" stale tasks."
+	}
+	return ""
+}
+
+// ParseError is an LLM response that couldn't be turned into a decision.
+type ParseError struct {
+	TaskID   string
+	Response string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("unparseable LLM response for task %s: %v", e.TaskID, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
 func ParseDecisionResponse(response string, taskID string) Decision {
+	decision, _ := parseDecision(response, taskID)
+	return decision
+}
+
+func parseDecision(response string, taskID string) (Decision, error) {
 	start := strings.Index(response, "{")
 	end := strings.LastIndex(response, "}")
-	if start == -1 || end == -1 {
-		return Decision{TaskID: taskID, Action: "skip", Reasoning: "Failed to parse LLM response"}
+	if start == -1 || end < start {
+		if decision, ok := recoverTruncated(response, taskID); ok {
+			return decision, nil
+		}
+		return Decision{TaskID: taskID, Action: "skip", Reasoning: "Failed to parse LLM response"}, errors.New("no JSON object in LLM response")
 	}
 	jsonStr := response[start : end+1]
 	var result struct {
-		Action       string   `json:"action"`
-		Priority     *int     `json:"priority"`
-		NewContent   *string  `json:"new_content"`
-		Subtasks     []string `json:"subtasks"`
-		Reasoning    string   `json:"reasoning"`
-		InertiaScore float64  `json:"inertia_score"`
+		rawAction
+		// Actions, when given, replaces the single action above.
+		Actions      []rawAction `json:"actions"`
+		Reasoning    string      `json:"reasoning"`
+		InertiaScore float64     `json:"inertia_score"`
 	}
 	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		return Decision{TaskID: taskID, Action: "skip", Reasoning: fmt.Sprintf("JSON parse error: %v", err)}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			if decision, ok := recoverTruncated(response, taskID); ok {
+				return decision, nil
+			}
+		}
+		return Decision{TaskID: taskID, Action: "skip", Reasoning: fmt.Sprintf("JSON parse error: %v", err)}, err
 	}
-	return Decision{
-		TaskID:       taskID,
-		Action:       result.Action,
-		Priority:     result.Priority,
-		NewContent:   result.NewContent,
-		Subtasks:     result.Subtasks,
-		Reasoning:    result.Reasoning,
-		InertiaScore: result.InertiaScore,
+	actions := result.Actions
+	if len(actions) == 0 {
+		actions = []rawAction{result.rawAction}
 	}
+	subs := make([]SubAction, len(actions))
+	for i, action := range actions {
+		sub, reasoning, err := action.resolve(taskID)
+		if err != nil {
+			return Decision{TaskID: taskID, Action: "skip", Reasoning: reasoning}, err
+		}
+		subs[i] = sub
+	}
+	decision := Decision{TaskID: taskID, Reasoning: result.Reasoning, InertiaScore: result.InertiaScore}
+	if len(subs) == 1 {
+		// A lone action is taken as given, whatever it is.
+		sub := subs[0]
+		decision.Action, decision.Priority, decision.NewContent, decision.Subtasks, decision.Due =
+			sub.Action, sub.Priority, sub.NewContent, sub.Subtasks, sub.Due
+		return decision, nil
+	}
+	return withSubActions(decision, subs), nil
+}
+
+// recoverTruncated wraps RecoverPartialDecision with a warning, so salvaged
+// decisions stand out in the logs.
+func recoverTruncated(response, taskID string) (Decision, bool) {
+	decision, ok := RecoverPartialDecision(response, taskID)
+	if ok {
+		Log.Warn("Recovered a truncated LLM response for task %s as %s", taskID, decision.Action)
+	}
+	return decision, ok
+}
+
+// ResolveDueDate turns a relative due date such as "+7d" or "+2w" into an
+// absolute YYYY-MM-DD date counted from now; absolute dates are checked and
+// passed through.
+func ResolveDueDate(due string, now time.Time) (string, error) {
+	due = strings.TrimSpace(due)
+	if rest, ok := strings.CutPrefix(due, "+"); ok && len(rest) > 1 {
+		n, err := strconv.Atoi(rest[:len(rest)-1])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid relative due date %q", due)
+		}
+		switch rest[len(rest)-1] {
+		case 'd':
+			return now.AddDate(0, 0, n).Format("2006-01-02"), nil
+		case 'w':
+			return now.AddDate(0, 0, 7*n).Format("2006-01-02"), nil
+		}
+		return "", fmt.Errorf("invalid relative due date %q: unit must be d or w", due)
+	}
+	if _, err := time.Parse("2006-01-02", due); err != nil {
+		return "", fmt.Errorf("due date %q is neither +Nd, +Nw nor YYYY-MM-DD", due)
+	}
+	return due, nil
 }
 
 func ExecuteDecisionsParallel(decisions []Decision) {
+	ExecuteDecisionsParallelContext(context.Background(), decisions)
+}
+
+// ExecuteDecisionsParallelContext executes decisions concurrently, skipping
+// any that have not started once ctx is cancelled. It returns how many were
+// executed. Decompositions share one fetch of the existing subtasks.
+func ExecuteDecisionsParallelContext(ctx context.Context, decisions []Decision) int {
 	var wg sync.WaitGroup
+	var executed atomic.Int64
+	index := &subtaskIndex{}
 	for _, decision := range decisions {
+		if ctx.Err() != nil {
+			break
+		}
 		wg.Add(1)
 		go func(d Decision) {
 			defer wg.Done()
-			ExecuteDecision(d)
+			if ctx.Err() != nil {
+				return
+			}
+			err := executeDecision(d, index)
+			if ActiveJournal != nil {
+				ActiveJournal.Record(d.TaskID, err)
+			}
+			executed.Add(1)
 		}(decision)
 	}
 	wg.Wait()
+	return int(executed.Load())
+}
+
+// ExecuteDecision applies decision through Source. Failed commands are logged
+// and returned, joined, as *ExecutionError values; the remaining commands
+// still run.
+func ExecuteDecision(decision Decision) error {
+	return executeDecision(decision, &subtaskIndex{})
 }
 
-func ExecuteDecision(decision Decision) {
+func executeDecision(decision Decision, index *subtaskIndex) error {
+	routeTask(decision.TaskID, decision.SourceID)
+	if len(decision.Actions) > 0 {
+		var errs []error
+		for _, single := range subDecisions(decision) {
+			errs = append(errs, executeDecision(single, index))
+		}
+		return errors.Join(errs...)
+	}
+	if decision.Action == "decompose" {
+		return executeDecompose(decision, index)
+	}
+	var errs []error
+	for _, cmd := range PreviewDecision(decision) {
+		if err := CommandRunner.Run(cmd[0], cmd[1:]...); err != nil {
+			Log.Error("Failed to %s task %s: %v", decision.Action, decision.TaskID, err)
+			errs = append(errs, &ExecutionError{TaskID: decision.TaskID, Action: decision.Action, Err: err})
+			continue
+		}
+		switch decision.Action {
+		case "reprioritize":
+			if decision.CurrentPriority != 0 {
+				recordUndo(decision, UndoFieldPriority, NormalizePriority(decision.CurrentPriority, Settings.PriorityScale), NormalizePriority(*decision.Priority, Settings.PriorityScale))
+			}
+		case "recontextualize":
+			if decision.CurrentContent != "" {
+				recordUndo(decision, UndoFieldContent, decision.CurrentContent, *decision.NewContent)
+			}
+		case "defer":
+			recordUndo(decision, UndoFieldDue, decision.CurrentDue, *decision.Due)
+		case "ice-box", ActionMerge:
+			recordIceBoxUndo(decision)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// executeDecompose adds each planned subtask that doesn't already exist
+// under the parent.
+func executeDecompose(decision Decision, index *subtaskIndex) error {
+	subtasks := decomposeSubtasks(decision)
+	if len(subtasks) == 0 {
+		return nil
+	}
+	var errs []error
+	existing := index.contents(decision.TaskID)
+	for _, subtask := range subtasks {
+		if existing[normalizeText(subtask)] {
+			Log.Info("Subtask %q already exists under task %s, skipping", subtask, decision.TaskID)
+			continue
+		}
+		cmd := subtaskCommand(decision, subtask)
+		if err := CommandRunner.Run(cmd[0], cmd[1:]...); err != nil {
+			Log.Error("Failed to add subtask to %s: %v", decision.TaskID, err)
+			errs = append(errs, &ExecutionError{TaskID: decision.TaskID, Action: decision.Action, Err: err})
+			continue
+		}
+		recordUndo(decision, UndoFieldSubtask, "", subtask)
+	}
+	return errors.Join(errs...)
+}
+
+// subtaskCommand adds subtask under the decision's task, inheriting the
+// parent's labels and priority as configured.
+func subtaskCommand(decision Decision, subtask string) []string {
+	var labels []string
+	if Settings.InheritLabels {
+		labels = decision.CurrentLabels
+	}
+	priority := ""
+	if Settings.InheritPriority && decision.CurrentPriority != 0 {
+		priority = NormalizePriority(decision.CurrentPriority, Settings.PriorityScale)
+	}
+	return Source.AddSubtaskCommand(decision.TaskID, subtask, labels, priority)
+}
+
+// decomposeSubtasks is the deduplicated, capped list of subtasks a
+// decompose decision will add.
+func decomposeSubtasks(decision Decision) []string {
+	return capSubtasks(decision, DedupeSubtasks(decision.Subtasks))
+}
+
+// ExistingSubtaskContents re-fetches tasks and returns the normalized content
+// of every current child of parentID. If the fetch fails it warns and returns
+// an empty set, so decompose goes ahead as before.
+func ExistingSubtaskContents(parentID string) map[string]bool {
+	return (&subtaskIndex{}).contents(parentID)
+}
+
+// subtaskIndex re-fetches tasks on first use only, so the decompositions of
+// one run share a single fetch.
+type subtaskIndex struct {
+	once     sync.Once
+	byParent map[string]map[string]bool
+	err      error
+}
+
+// contents is the normalized content of every child of parentID, or an
+// empty set when the fetch failed.
+func (x *subtaskIndex) contents(parentID string) map[string]bool {
+	x.once.Do(func() {
+		x.byParent = make(map[string]map[string]bool)
+		var tasks []Task
+		tasks, x.err = FetchAllTasks()
+		for _, task := range tasks {
+			if task.ParentID == nil {
+				continue
+			}
+			if x.byParent[*task.ParentID] == nil {
+				x.byParent[*task.ParentID] = make(map[string]bool)
+			}
+			x.byParent[*task.ParentID][normalizeText(task.Content)] = true
+		}
+	})
+	if x.err != nil {
+		Log.Warn("Could not check existing subtasks of %s: %v", parentID, x.err)
+	}
+	existing := make(map[string]bool, len(x.byParent[parentID]))
+	for content := range x.byParent[parentID] {
+		existing[content] = true
+	}
+	return existing
+}
+
+// PreviewDecision returns the commands ExecuteDecision would run for
+// decision, without running them.
+func PreviewDecision(decision Decision) [][]string {
+	if len(decision.Actions) > 0 {
+		var commands [][]string
+		for _, single := range subDecisions(decision) {
+			commands = append(commands, PreviewDecision(single)...)
+		}
+		return commands
+	}
 	switch decision.Action {
-	case "skip":
-		return
 	case "reprioritize":
-		if decision.Priority != nil {
-			if err := CommandRunner.Run("td", "task", "update", decision.TaskID, "--priority", fmt.Sprintf("p%d", *decision.Priority)); err != nil {
-				log.Printf("Failed to reprioritize task %s: %v", decision.TaskID, err)
-			}
+		if decision.Priority == nil {
+			return nil
+		}
+		priority := NormalizePriority(*decision.Priority, Settings.PriorityScale)
+		if decision.CurrentPriority != 0 && priority == NormalizePriority(decision.CurrentPriority, Settings.PriorityScale) {
+			Log.Info("Task %s already %s, skipping", decision.TaskID, priority)
+			return nil
 		}
+		return [][]string{Source.PriorityCommand(decision.TaskID, priority)}
 	case "recontextualize":
 		if decision.NewContent != nil {
-			if err := CommandRunner.Run("td", "task", "update", decision.TaskID, "--content", *decision.NewContent); err != nil {
-				log.Printf("Failed to recontextualize task %s: %v", decision.TaskID, err)
-			}
+			return [][]string{Source.ContentCommand(decision.TaskID, *decision.NewContent)}
 		}
 	case "decompose":
-		for _, subtask := range decision.Subtasks {
-			if err := CommandRunner.Run("td", "task", "add", subtask, "--parent", decision.TaskID); err != nil {
-				log.Printf("Failed to add subtask to %s: %v", decision.TaskID, err)
-			}
+		var commands [][]string
+		for _, subtask := range decomposeSubtasks(decision) {
+			commands = append(commands, subtaskCommand(decision, subtask))
 		}
-	case "ice-box":
-		log.Printf("Ice-boxing task %s (implement project move)", decision.TaskID)
+		return commands
+	case "defer":
+		if decision.Due != nil {
+			return [][]string{Source.DueCommand(decision.TaskID, *decision.Due)}
+		}
+	case "ice-box", ActionMerge:
+		return IceBox.Commands(decision)
+	}
+	return nil
+}
+
+// capSubtasks enforces Settings.MaxSubtasks on a decomposition, either by
+// dropping the excess or, with PlanOnOverflow, by replacing the whole list
+// with a single planning subtask.
+func capSubtasks(decision Decision, subtasks []string) []string {
+	limit := Settings.MaxSubtasks
+	if limit <= 0 || len(subtasks) <= limit {
+		return subtasks
+	}
+	if Settings.PlanOnOverflow {
+		Log.Warn("Task %s decomposed into %d subtasks (cap %d), adding a plan subtask instead", decision.TaskID, len(subtasks), limit)
+		return []string{"Plan: " + decision.CurrentContent}
 	}
+	Log.Warn("Task %s decomposed into %d subtasks, dropping %d over the cap of %d", decision.TaskID, len(subtasks), len(subtasks)-limit, limit)
+	return subtasks[:limit]
 }
@@ -11,3 +11,13 @@ func TestEngine(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Engine Suite")
 }
+
+var _ = BeforeSuite(func() {
+	Log = NopLogger{}
+	FetchBackoff = 0
+	LLMRetryBackoff = 0
+})
+
+var _ = BeforeEach(func() {
+	Settings = DefaultConfig()
+})
@@ -1,15 +1,26 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
+// MockRunner records commands from many goroutines at once, so every field
+// access goes through mu.
 type MockRunner struct {
+	mu             sync.Mutex
 	CalledCommands [][]string
 	Outputs        map[string][]byte
 	Errors         map[string]error
@@ -17,16 +28,22 @@ type MockRunner struct {
 }
 
 func (m *MockRunner) Run(name string, args ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.CalledCommands = append(m.CalledCommands, append([]string{name}, args...))
 	return m.Errors[name]
 }
 
 func (m *MockRunner) Output(name string, args ...string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.CalledCommands = append(m.CalledCommands, append([]string{name}, args...))
 	return m.Outputs[name], m.Errors[name]
 }
 
 func (m *MockRunner) RunWithStdin(stdin string, name string, args ...string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.StdinSent = stdin
 	m.CalledCommands = append(m.CalledCommands, append([]string{name}, args...))
 	return m.Outputs[name], m.Errors[name]
@@ -84,6 +101,30 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 			})
 		})
 
+		Context("when printing a single task's prompt", func() {
+			It("should contextualize the task with that ID without calling the LLM", func() {
+				mock.Outputs["td"] = []byte(`{"results": [
+					{"id": "1", "content": "Water plants"},
+					{"id": "2", "content": "Write journaling prompts"}
+				]}`)
+				tasks, err := FetchAllTasks()
+				Expect(err).NotTo(HaveOccurred())
+				inertiaCtx := &InertiaContext{Gazetteer: Gazetteer{Concepts: []Entity{{Name: "Journaling", Context: "Daily habit"}}}}
+
+				prompt, err := PromptForTask(tasks, "2", inertiaCtx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(prompt).To(ContainSubstring("Task: Write journaling prompts"))
+				Expect(prompt).To(ContainSubstring("Journaling"))
+				Expect(prompt).NotTo(ContainSubstring("Water plants"))
+				Expect(mock.StdinSent).To(BeEmpty())
+			})
+
+			It("should say so when no task has that ID", func() {
+				_, err := PromptForTask([]Task{{ID: "1"}}, "42", &InertiaContext{})
+				Expect(err).To(MatchError("task 42 not found among 1 fetched tasks"))
+			})
+		})
+
 		Context("when preparing tasks for processing", func() {
 			It("should filter for leaf nodes to prevent redundant updates to parent tasks", func() {
 				parentID := "p1"
@@ -101,6 +142,111 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 				Expect(ids).ToNot(ContainElement("p1"))
 			})
 
+			It("should refuse to proceed when fewer leaf tasks than --min-tasks are fetched", func() {
+				mock.Outputs["td"] = []byte(`{"results": []}`)
+				tasks, err := FetchAllTasks()
+				Expect(err).NotTo(HaveOccurred())
+				leafTasks := FilterLeafNodes(tasks)
+
+				Expect(CheckMinTasks(len(leafTasks), 1, false)).To(MatchError(ContainSubstring("no leaf tasks fetched")))
+				Expect(CheckMinTasks(len(leafTasks), 0, false)).To(Succeed())
+				Expect(CheckMinTasks(len(leafTasks), 5, true)).To(Succeed())
+				Expect(CheckMinTasks(3, 5, true)).To(MatchError(ContainSubstring("only 3 leaf tasks")))
+			})
+
+			It("should detect circular parent references and treat them as non-leaf", func() {
+				a, b := "a", "b"
+				tasks := []Task{
+					{ID: "a", ParentID: &b},
+					{ID: "b", ParentID: &a},
+					{ID: "l1"},
+				}
+
+				Expect(DetectParentCycles(tasks)).To(Equal([]string{"a", "b"}))
+				leafTasks := FilterLeafNodes(tasks)
+				Expect(leafTasks).To(HaveLen(1))
+				Expect(leafTasks[0].ID).To(Equal("l1"))
+			})
+
+			It("should report tasks whose parent does not exist", func() {
+				missing := "gone"
+				tasks := []Task{
+					{ID: "c1", ParentID: &missing},
+					{ID: "l1"},
+				}
+
+				Expect(DanglingParents(tasks)).To(Equal([]string{"c1"}))
+				Expect(DetectParentCycles(tasks)).To(BeEmpty())
+				Expect(FilterLeafNodes(tasks)).To(HaveLen(2))
+			})
+
+			It("should keep only tasks older than the minimum age", func() {
+				now := NowFunc()
+				tasks := []Task{
+					{ID: "old", AddedAt: now.Add(-30 * 24 * time.Hour)},
+					{ID: "new", AddedAt: now.Add(-2 * 24 * time.Hour)},
+					{ID: "edge", AddedAt: now.Add(-14 * 24 * time.Hour)},
+					{ID: "undated"},
+				}
+
+				minAge, err := ParseDuration("14d")
+				Expect(err).NotTo(HaveOccurred())
+				stale := FilterByAge(tasks, minAge, now)
+				Expect(stale).To(HaveLen(2))
+				Expect([]string{stale[0].ID, stale[1].ID}).To(Equal([]string{"old", "edge"}))
+			})
+
+			It("should sample the same tasks for the same seed", func() {
+				var tasks []Task
+				for _, id := range strings.Fields("a b c d e f g h i j k l m n o p") {
+					tasks = append(tasks, Task{ID: id})
+				}
+
+				first := SampleTasks(tasks, 4, 42)
+				Expect(first).To(HaveLen(4))
+				Expect(SampleTasks(tasks, 4, 42)).To(Equal(first))
+				Expect(SampleTasks(tasks, 4, 7)).NotTo(Equal(first))
+				Expect(SampleTasks(tasks, 50, 42)).To(HaveLen(16))
+			})
+
+			It("should exclude leaf tasks already in the ice-box project when one is set", func() {
+				tasks := []Task{
+					{ID: "a", ProjectID: "inbox"},
+					{ID: "b", ProjectID: "icebox"},
+					{ID: "c", ProjectID: "work"},
+				}
+
+				active := ExcludeIceBoxed(FilterLeafNodes(tasks), "icebox")
+				Expect(active).To(HaveLen(2))
+				Expect([]string{active[0].ID, active[1].ID}).To(Equal([]string{"a", "c"}))
+				Expect(ExcludeIceBoxed(FilterLeafNodes(tasks), "")).To(HaveLen(3))
+			})
+
+			It("should exclude tasks carrying an excluded label, ignoring case", func() {
+				tasks := []Task{
+					{ID: "a", Labels: []string{"work"}},
+					{ID: "b", Labels: []string{"errand", "Waiting"}},
+					{ID: "c"},
+				}
+
+				kept := ExcludeByLabels(tasks, []string{"waiting", "someday"})
+				Expect(kept).To(HaveLen(2))
+				Expect([]string{kept[0].ID, kept[1].ID}).To(Equal([]string{"a", "c"}))
+				Expect(ExcludeByLabels(tasks, nil)).To(HaveLen(3))
+			})
+
+			It("should resolve a project ID by name via the 'td' CLI", func() {
+				mock.Outputs["td"] = []byte(`{"results": [{"id": "p1", "name": "Inbox"}, {"id": "p9", "name": "Ice Box"}]}`)
+
+				id, err := ResolveProjectID("ice box")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(id).To(Equal("p9"))
+				Expect(mock.CalledCommands).To(ContainElement([]string{"td", "project", "list", "--json"}))
+
+				_, err = ResolveProjectID("Someday")
+				Expect(err).To(HaveOccurred())
+			})
+
 			It("should identify related concepts in the gazetteer via keyword matching", func() {
 				ctx := &InertiaContext{
 					Gazetteer: Gazetteer{
@@ -116,6 +262,124 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 				Expect(taskCtx.RelatedConcepts).To(HaveLen(1))
 				Expect(taskCtx.RelatedConcepts[0].Name).To(Equal("Journaling"))
 			})
+
+			It("should match concepts on task labels unless label matching is disabled", func() {
+				ctx := &InertiaContext{
+					Gazetteer: Gazetteer{
+						Concepts: []Entity{{Name: "Journaling", Context: "10 years"}},
+					},
+				}
+				task := Task{Content: "Buy a notebook", Labels: []string{"journaling"}}
+
+				taskCtx := ContextualizeTask(task, ctx)
+				Expect(taskCtx.RelatedConcepts).To(HaveLen(1))
+				Expect(taskCtx.RelatedConcepts[0].Name).To(Equal("Journaling"))
+
+				Settings.MatchLabels = false
+				Expect(ContextualizeTask(task, ctx).RelatedConcepts).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Concept matching", func() {
+		var ctx *InertiaContext
+		BeforeEach(func() {
+			ctx = &InertiaContext{
+				Gazetteer: Gazetteer{Concepts: []Entity{{Name: "Machine Learning"}}},
+			}
+		})
+
+		It("should not match a multi-word concept on a single shared word", func() {
+			taskCtx := ContextualizeTask(Task{Content: "learning to cook"}, ctx)
+			Expect(taskCtx.RelatedConcepts).To(BeEmpty())
+		})
+
+		It("should match a multi-word concept on the full phrase", func() {
+			taskCtx := ContextualizeTask(Task{Content: "study machine learning"}, ctx)
+			Expect(taskCtx.RelatedConcepts).To(HaveLen(1))
+		})
+
+		It("should match on a configurable fraction of keywords", func() {
+			Settings.ConceptMatchFraction = 0.5
+			taskCtx := ContextualizeTask(Task{Content: "learning to cook"}, ctx)
+			Expect(taskCtx.RelatedConcepts).To(HaveLen(1))
+		})
+
+		It("should match a concept on any of its aliases", func() {
+			ctx.Gazetteer.Concepts = append(ctx.Gazetteer.Concepts, Entity{Name: "Fitness", Aliases: []string{"gym", "workout", " "}})
+			taskCtx := ContextualizeTask(Task{Content: "go to the gym"}, ctx)
+			Expect(taskCtx.RelatedConcepts).To(HaveLen(1))
+			Expect(taskCtx.RelatedConcepts[0].Name).To(Equal("Fitness"))
+			Expect(ContextualizeTask(Task{Content: "file taxes"}, ctx).RelatedConcepts).To(BeEmpty())
+		})
+
+		It("should read aliases from the context file and keep working without them", func() {
+			var gazetteer Gazetteer
+			Expect(json.Unmarshal([]byte(`{"concepts": [{"name": "Fitness", "aliases": ["running"]}, {"name": "Guitar"}]}`), &gazetteer)).To(Succeed())
+			Expect(gazetteer.Concepts[0].Aliases).To(Equal([]string{"running"}))
+			Expect(gazetteer.Concepts[1].Aliases).To(BeNil())
+			taskCtx := ContextualizeTask(Task{Content: "Running shoes, guitar strings"}, &InertiaContext{Gazetteer: gazetteer})
+			Expect(taskCtx.RelatedConcepts).To(HaveLen(2))
+		})
+
+		It("should match camelCase and emoji-laden tasks when normalizing", func() {
+			ctx.Gazetteer.People = []Entity{{Name: "Dana Scully"}}
+			ctx.Gazetteer.Projects = []Entity{{Name: "Home-Lab"}}
+			task := Task{Content: "📞FollowUpWithDanaScully about the HomeLab!"}
+			Expect(ContextualizeTask(task, ctx).RelatedPeople).To(BeEmpty())
+			Expect(ContextualizeTask(task, ctx).RelatedProjects).To(BeEmpty())
+
+			Settings.NormalizeMatching = true
+			taskCtx := ContextualizeTask(task, ctx)
+			Expect(taskCtx.RelatedPeople).To(HaveLen(1))
+			Expect(taskCtx.RelatedProjects).To(HaveLen(1))
+		})
+	})
+
+	Describe("Context pruning", func() {
+		span := func(years string) json.RawMessage { return json.RawMessage(years) }
+		concepts := []Entity{
+			{Name: "Running", SpanYears: span(`3`)},
+			{Name: "Music", SpanYears: span(`12`)},
+			{Name: "Gardening", SpanYears: span(`8`)},
+			{Name: "Chess", SpanYears: span(`8`)},
+		}
+
+		It("should keep the two longest-span concepts with k=2", func() {
+			Expect(TopRelatedConcepts(concepts, 2)).To(Equal([]Entity{concepts[1], concepts[3]}))
+		})
+
+		It("should keep every concept in order when k is zero or large enough", func() {
+			Expect(TopRelatedConcepts(concepts, 0)).To(Equal(concepts))
+			Expect(TopRelatedConcepts(concepts, 4)).To(Equal(concepts))
+		})
+
+		It("should list only the top concepts in the prompt", func() {
+			Settings.MaxContextEntities = 2
+			prompt := BuildDecisionPrompt(TaskContext{Task: Task{Content: "Practice"}, RelatedConcepts: concepts})
+			Expect(prompt).To(ContainSubstring("- Music (12 years)"))
+			Expect(prompt).To(ContainSubstring("- Chess (8 years)"))
+			Expect(prompt).NotTo(ContainSubstring("Gardening"))
+			Expect(prompt).To(ContainSubstring("(2 more concepts omitted)"))
+		})
+	})
+
+	Describe("Related People in Prompt", func() {
+		ctx := &InertiaContext{Gazetteer: Gazetteer{People: []Entity{
+			{Name: "Dana", Context: "Sister, lives abroad", EmotionalValence: "warm"},
+			{Name: "Walter", Context: "Former manager"},
+		}}}
+
+		It("should list the people a task mentions with their context and valence", func() {
+			prompt := BuildDecisionPrompt(ContextualizeTask(Task{Content: "Call Dana about the visit"}, ctx))
+			Expect(prompt).To(ContainSubstring("Related people:\n- Dana (warm): Sister, lives abroad\n\n"))
+			Expect(prompt).NotTo(ContainSubstring("Walter"))
+		})
+
+		It("should leave the section out when disabled or when nobody matched", func() {
+			Expect(BuildDecisionPrompt(ContextualizeTask(Task{Content: "Water plants"}, ctx))).NotTo(ContainSubstring("Related people"))
+			Settings.PromptPeople = false
+			Expect(BuildDecisionPrompt(ContextualizeTask(Task{Content: "Call Dana"}, ctx))).NotTo(ContainSubstring("Related people"))
 		})
 	})
 
@@ -151,6 +415,26 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 			})
 		})
 
+		Context("span_years parsing", func() {
+			DescribeTable("GetSpanYears",
+				func(raw string, expected float64) {
+					entity := Entity{SpanYears: json.RawMessage(raw)}
+					Expect(entity.GetSpanYears()).To(BeNumerically("~", expected))
+				},
+				Entry("numeric", `10`, 10.0),
+				Entry("numeric string", `"10"`, 10.0),
+				Entry("range string resolves to midpoint", `"10-15"`, 12.5),
+				Entry("approximate string", `"~8"`, 8.0),
+				Entry("string with unit suffix", `"12+ years"`, 12.0),
+				Entry("unknown string", `"unknown"`, 0.0),
+				Entry("empty", ``, 0.0),
+				Entry("year range object", `{"start": 2008, "end": 2024}`, 16.0),
+				Entry("open-ended year range runs to the current year", `{"start": 2016}`, 10.0),
+				Entry("reversed year range", `{"start": 2024, "end": 2008}`, 16.0),
+				Entry("object without a start", `{"end": 2024}`, 0.0),
+			)
+		})
+
 		Context("State Alignment (30%)", func() {
 			It("should include state markers in the prompt for LLM alignment", func() {
 				taskCtx := TaskContext{
@@ -162,6 +446,47 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 				Expect(prompt).To(ContainSubstring("Mood: inspired"))
 				Expect(prompt).To(ContainSubstring("Environment: home"))
 			})
+
+			It("should treat a task with no creation date as age 0 and say its age is unknown", func() {
+				taskCtx := ContextualizeTask(Task{ID: "1", Content: "Undated chore"}, &InertiaContext{})
+				Expect(taskCtx.AgeDays).To(Equal(0))
+				Expect(taskCtx.AgeUnknown).To(BeTrue())
+				prompt := BuildDecisionPrompt(taskCtx)
+				Expect(prompt).To(ContainSubstring("Created: age unknown"))
+				Expect(prompt).NotTo(ContainSubstring("days ago"))
+
+				dated := ContextualizeTask(Task{ID: "2", Content: "Dated chore", AddedAt: NowFunc().AddDate(0, 0, -3)}, &InertiaContext{})
+				Expect(dated.AgeUnknown).To(BeFalse())
+				Expect(BuildDecisionPrompt(dated)).To(ContainSubstring("Created: 3 days ago"))
+			})
+
+			It("should not let an unknown age pass the decompose and ice-box thresholds", func() {
+				Backend = backendFunc(func(string) (string, error) {
+					return `{"action": "ice-box", "reasoning": "ancient"}`, nil
+				})
+				DeferCleanup(func() { Backend = defaultBackend() })
+				decision := ProcessTask(Task{ID: "1", Content: "Undated chore"}, &InertiaContext{})
+				Expect(decision.AgeDays).To(Equal(0))
+				decisions := ApplyAgeThresholds([]Decision{decision}, Settings.DecomposeAgeDays, Settings.IceBoxAgeDays)
+				Expect(decisions[0].Action).To(Equal("skip"))
+			})
+
+			It("should include work volatility and bias the prompt by its level", func() {
+				taskCtx := TaskContext{
+					Task:  Task{Content: "Plan quarter"},
+					State: State{WorkVolatility: "high"},
+				}
+				prompt := BuildDecisionPrompt(taskCtx)
+				Expect(prompt).To(ContainSubstring("Work volatility: high"))
+				Expect(prompt).To(ContainSubstring(VolatilityBias(taskCtx.State)))
+
+				high := VolatilityBias(State{WorkVolatility: "high"})
+				low := VolatilityBias(State{WorkVolatility: "low"})
+				Expect(high).To(ContainSubstring("ice-box"))
+				Expect(low).To(ContainSubstring("decompose"))
+				Expect(high).NotTo(Equal(low))
+				Expect(VolatilityBias(State{})).To(BeEmpty())
+			})
 		})
 	})
 
@@ -186,10 +511,288 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 				Expect(decision.Action).To(Equal("reprioritize"))
 				Expect(*decision.Priority).To(Equal(1))
 			})
+
+			It("should parse 'defer' and resolve a relative due date against now", func() {
+				resp := `{"action": "defer", "due": "+7d", "reasoning": "not this week"}`
+				decision := ParseDecisionResponse(resp, "123")
+				Expect(decision.Action).To(Equal("defer"))
+				Expect(*decision.Due).To(Equal("2026-03-03"))
+			})
+
+			It("should pass absolute due dates through and reject malformed ones", func() {
+				decision := ParseDecisionResponse(`{"action": "defer", "due": "2026-04-01"}`, "123")
+				Expect(*decision.Due).To(Equal("2026-04-01"))
+
+				decision = ParseDecisionResponse(`{"action": "defer", "due": "next week"}`, "123")
+				Expect(decision.Action).To(Equal("skip"))
+				Expect(decision.Due).To(BeNil())
+
+				decision = ParseDecisionResponse(`{"action": "defer"}`, "123")
+				Expect(decision.Action).To(Equal("skip"))
+			})
+		})
+
+		Context("when the LLM response cannot be parsed", func() {
+			BeforeEach(func() {
+				Backend = backendFunc(func(prompt string) (string, error) {
+					return "I think you should skip it", nil
+				})
+				DeferCleanup(func() { Backend = defaultBackend() })
+			})
+
+			It("should fall back to skip by default", func() {
+				decisions, err := ProcessTasksParallelContext(context.Background(), []Task{{ID: "1"}}, &InertiaContext{}, 1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decisions).To(HaveLen(1))
+				Expect(decisions[0].Action).To(Equal("skip"))
+			})
+
+			It("should return the raw response as an error with --fail-fast-on-parse-errors", func() {
+				Settings.FailFastOnParseErrors = true
+				_, err := ProcessTasksParallelContext(context.Background(), []Task{{ID: "1"}, {ID: "2"}}, &InertiaContext{}, 1)
+				var parseErr *ParseError
+				Expect(errors.As(err, &parseErr)).To(BeTrue())
+				Expect(parseErr.Response).To(Equal("I think you should skip it"))
+				Expect(err.Error()).To(ContainSubstring("unparseable LLM response for task"))
+			})
 		})
 	})
 
 	Describe("Concurrency & Execution Safety", func() {
+		It("should record commands from many goroutines without racing", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					mock.Run("td", "task", "update")
+					mock.RunWithStdin("prompt", "openclaw", "chat")
+				}()
+			}
+			wg.Wait()
+			Expect(mock.CalledCommands).To(HaveLen(100))
+		})
+
+		It("should execute decisions in parallel without racing", func() {
+			priority := 1
+			var decisions []Decision
+			for i := 0; i < 50; i++ {
+				decisions = append(decisions, Decision{TaskID: "t", Action: "reprioritize", Priority: &priority})
+			}
+			ExecuteDecisionsParallel(decisions)
+			Expect(mock.CalledCommands).To(HaveLen(50))
+		})
+
+		It("should return decisions in input order when asked, however they complete", func() {
+			Backend = backendFunc(func(prompt string) (string, error) {
+				// Earlier tasks answer last.
+				var n int
+				fmt.Sscanf(strings.SplitN(prompt, "\n", 2)[0], "Task: Task %d", &n)
+				time.Sleep(time.Duration(8-n) * 2 * time.Millisecond)
+				return `{"action": "skip", "reasoning": "ok"}`, nil
+			})
+			DeferCleanup(func() { Backend = defaultBackend() })
+			var tasks []Task
+			for i := 0; i < 8; i++ {
+				tasks = append(tasks, Task{ID: fmt.Sprint(i), Content: fmt.Sprintf("Task %d", i)})
+			}
+
+			decisions, err := ProcessTasksParallelContext(context.Background(), tasks, &InertiaContext{}, 8, WithOrderedResults())
+			Expect(err).NotTo(HaveOccurred())
+			var ids []string
+			for _, d := range decisions {
+				ids = append(ids, d.TaskID)
+			}
+			Expect(ids).To(Equal([]string{"0", "1", "2", "3", "4", "5", "6", "7"}))
+		})
+
+		It("should stream one decision per task", func() {
+			Backend = backendFunc(func(string) (string, error) { return `{"action": "skip", "reasoning": "ok"}`, nil })
+			DeferCleanup(func() { Backend = defaultBackend() })
+			var tasks []Task
+			for i := 0; i < 20; i++ {
+				tasks = append(tasks, Task{ID: fmt.Sprint(i), Content: fmt.Sprintf("Task %d", i)})
+			}
+
+			var ids []string
+			for d := range StreamDecisions(tasks, &InertiaContext{}, 4) {
+				ids = append(ids, d.TaskID)
+			}
+			Expect(ids).To(ConsistOf("0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16", "17", "18", "19"))
+		})
+
+		It("should stop starting tasks while the stream's consumer falls behind", func() {
+			var calls atomic.Int32
+			Backend = backendFunc(func(string) (string, error) {
+				calls.Add(1)
+				return `{"action": "skip", "reasoning": "ok"}`, nil
+			})
+			DeferCleanup(func() { Backend = defaultBackend() })
+			var tasks []Task
+			for i := 0; i < 50; i++ {
+				tasks = append(tasks, Task{ID: fmt.Sprint(i), Content: "Task"})
+			}
+
+			stream := StreamDecisions(tasks, &InertiaContext{}, 2)
+			// Two buffered, one held by the stream and two waiting to send.
+			Consistently(calls.Load, "50ms").Should(BeNumerically("<=", 5))
+			received := 0
+			for range stream {
+				received++
+			}
+			Expect(received).To(Equal(50))
+		})
+
+		It("should yield identical decisions from two deterministic runs", func() {
+			Settings.Deterministic = true
+			Backend = backendFunc(func(prompt string) (string, error) {
+				time.Sleep(time.Duration(rand.IntN(3)) * time.Millisecond)
+				task := strings.TrimPrefix(strings.SplitN(prompt, "\n", 2)[0], "Task: ")
+				return fmt.Sprintf(`{"action": "skip", "reasoning": "looked at %s"}`, task), nil
+			})
+			DeferCleanup(func() { Backend = defaultBackend() })
+			var tasks []Task
+			for _, id := range []string{"7", "3", "9", "1", "5", "2", "8"} {
+				tasks = append(tasks, Task{ID: id, Content: "Task " + id})
+			}
+
+			first, err := ProcessTasksParallelContext(context.Background(), tasks, &InertiaContext{}, 4)
+			Expect(err).NotTo(HaveOccurred())
+			second, err := ProcessTasksParallelContext(context.Background(), tasks, &InertiaContext{}, 4)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(Equal(first))
+			Expect(first[0].TraceID).NotTo(Equal(first[1].TraceID))
+			var ids []string
+			for _, d := range first {
+				ids = append(ids, d.TaskID)
+			}
+			Expect(ids).To(Equal([]string{"1", "2", "3", "5", "7", "8", "9"}))
+		})
+
+		It("should abort the run when the LLM command is not installed", func() {
+			mock.Errors["openclaw"] = &exec.Error{Name: "openclaw", Err: exec.ErrNotFound}
+			tasks := []Task{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+			_, err := ProcessTasksParallelContext(context.Background(), tasks, &InertiaContext{}, 1)
+			Expect(err).To(MatchError(exec.ErrNotFound))
+			Expect(len(mock.CalledCommands)).To(BeNumerically("<", 3))
+		})
+
+		It("should turn an ordinary command failure into a skip", func() {
+			mock.Errors["openclaw"] = errors.New("exit status 1")
+			tasks := []Task{{ID: "1"}, {ID: "2"}}
+
+			decisions, err := ProcessTasksParallelContext(context.Background(), tasks, &InertiaContext{}, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decisions).To(HaveLen(2))
+			Expect(decisions[0].Action).To(Equal("skip"))
+			Expect(decisions[0].Reasoning).To(ContainSubstring("LLM call failed"))
+		})
+
+		It("should classify runner errors as fatal or per-task", func() {
+			Expect(isFatalRunnerError(&exec.Error{Name: "td", Err: exec.ErrNotFound})).To(BeTrue())
+			Expect(isFatalRunnerError(&exec.ExitError{Stderr: []byte("Error: 401 Unauthorized")})).To(BeTrue())
+			Expect(isFatalRunnerError(&exec.ExitError{Stderr: []byte("model overloaded")})).To(BeFalse())
+			Expect(isFatalRunnerError(errors.New("exit status 1"))).To(BeFalse())
+			Expect(isFatalRunnerError(nil)).To(BeFalse())
+		})
+
+		It("should stop dispatching tasks once the context is cancelled", func() {
+			mock.Outputs["openclaw"] = []byte(`{"action": "skip", "reasoning": "fine"}`)
+			tasks := []Task{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			decisions, err := ProcessTasksParallelContext(ctx, tasks, &InertiaContext{}, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decisions).To(BeEmpty())
+			Expect(mock.CalledCommands).To(BeEmpty())
+		})
+
+		It("should finish in-flight tasks but dispatch no more once the deadline passes", func() {
+			Backend = backendFunc(func(prompt string) (string, error) {
+				if strings.Contains(prompt, "Slow") {
+					time.Sleep(200 * time.Millisecond)
+				}
+				return `{"action": "skip", "reasoning": "fine"}`, nil
+			})
+			DeferCleanup(func() { Backend = defaultBackend() })
+			tasks := []Task{{ID: "1", Content: "Quick"}, {ID: "2", Content: "Slow"}, {ID: "3", Content: "Quick again"}}
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			decisions, err := ProcessTasksParallelContext(ctx, tasks, &InertiaContext{}, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ctx.Err()).To(MatchError(context.DeadlineExceeded))
+			Expect(decisions).To(HaveLen(2))
+			Expect([]string{decisions[0].TaskID, decisions[1].TaskID}).To(Equal([]string{"1", "2"}))
+		})
+
+		It("should release queued tasks on cancellation and return promptly with partial results", func() {
+			var mu sync.Mutex
+			calls, inFlight, peak := 0, 0, 0
+			started := make(chan struct{}, 10)
+			release := make(chan struct{})
+			Backend = backendFunc(func(prompt string) (string, error) {
+				mu.Lock()
+				calls++
+				inFlight++
+				peak = max(peak, inFlight)
+				mu.Unlock()
+				started <- struct{}{}
+				<-release
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return `{"action": "skip", "reasoning": "fine"}`, nil
+			})
+			DeferCleanup(func() { Backend = defaultBackend() })
+			var tasks []Task
+			for _, id := range strings.Fields("1 2 3 4 5 6 7 8 9 10") {
+				tasks = append(tasks, Task{ID: id})
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-started
+				<-started
+				cancel()
+				close(release)
+			}()
+
+			begin := time.Now()
+			decisions, err := ProcessTasksParallelContext(ctx, tasks, &InertiaContext{}, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(time.Since(begin)).To(BeNumerically("<", time.Second))
+			Expect(decisions).To(HaveLen(2))
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(calls).To(Equal(2))
+			Expect(peak).To(BeNumerically("<=", 2))
+		})
+
+		It("should not start executing decisions once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			priority := 1
+			executed := ExecuteDecisionsParallelContext(ctx, []Decision{{TaskID: "1", Action: "reprioritize", Priority: &priority}})
+			Expect(executed).To(Equal(0))
+			Expect(mock.CalledCommands).To(BeEmpty())
+		})
+
+		It("should report progress exactly once per processed task", func() {
+			mock.Outputs["openclaw"] = []byte(`{"action": "skip", "reasoning": "fine"}`)
+			tasks := []Task{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+			var counts []int
+			decisions := ProcessTasksParallel(tasks, &InertiaContext{}, 2, WithProgress(func(completed, total int) {
+				Expect(total).To(Equal(3))
+				counts = append(counts, completed)
+			}))
+
+			Expect(decisions).To(HaveLen(3))
+			Expect(counts).To(Equal([]int{1, 2, 3}))
+		})
+
 		It("should execute 'td' update commands correctly", func() {
 			priority := 2
 			decision := Decision{
@@ -201,6 +804,24 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 			Expect(mock.CalledCommands).To(ContainElement([]string{"td", "task", "update", "123", "--priority", "p2"}))
 		})
 
+		It("should not update a task that already has the requested priority", func() {
+			priority := 2
+			decision := Decision{
+				TaskID:          "123",
+				Action:          "reprioritize",
+				Priority:        &priority,
+				CurrentPriority: 2,
+			}
+			ExecuteDecision(decision)
+			Expect(mock.CalledCommands).To(BeEmpty())
+		})
+
+		It("should carry the fetched priority through processing", func() {
+			mock.Outputs["openclaw"] = []byte(`{"action": "reprioritize", "priority": 3, "reasoning": "demote"}`)
+			decision := ProcessTask(Task{ID: "123", Priority: 3}, &InertiaContext{})
+			Expect(decision.CurrentPriority).To(Equal(3))
+		})
+
 		It("should handle decomposition by adding subtasks", func() {
 			decision := Decision{
 				TaskID:   "123",
@@ -210,5 +831,67 @@ var _ = Describe("Inertia Engine Orchestrator", func() {
 			ExecuteDecision(decision)
 			Expect(mock.CalledCommands).To(ContainElement([]string{"td", "task", "add", "sub 1", "--parent", "123"}))
 		})
+
+		It("should give subtasks the parent's labels and priority", func() {
+			ExecuteDecision(Decision{TaskID: "123", Action: "decompose", Subtasks: []string{"sub 1"}, CurrentLabels: []string{"work", "writing"}, CurrentPriority: 2})
+			Expect(mock.CalledCommands).To(ContainElement([]string{"td", "task", "add", "sub 1", "--parent", "123", "--labels", "work,writing", "--priority", "p2"}))
+		})
+
+		It("should add bare subtasks when inheritance is off", func() {
+			Settings.InheritLabels = false
+			Settings.InheritPriority = false
+			ExecuteDecision(Decision{TaskID: "123", Action: "decompose", Subtasks: []string{"sub 1"}, CurrentLabels: []string{"work"}, CurrentPriority: 2})
+			Expect(mock.CalledCommands).To(ContainElement([]string{"td", "task", "add", "sub 1", "--parent", "123"}))
+		})
+
+		It("should only add subtasks that do not already exist under the parent", func() {
+			mock.Outputs["td"] = []byte(`{"results": [
+				{"id": "123", "content": "Write report"},
+				{"id": "124", "content": "Gather figures", "parentId": "123"},
+				{"id": "200", "content": "Draft summary", "parentId": "999"}
+			]}`)
+			ExecuteDecision(Decision{TaskID: "123", Action: "decompose", Subtasks: []string{"gather figures", "Draft summary"}})
+
+			Expect(mock.CalledCommands).To(Equal([][]string{
+				{"td", "task", "list", "--json", "--full"},
+				{"td", "task", "add", "Draft summary", "--parent", "123"},
+			}))
+			Expect(ExistingSubtaskContents("123")).To(Equal(map[string]bool{"gather figures": true}))
+		})
+
+		It("should fetch existing subtasks once for all of a run's decompositions", func() {
+			mock.Outputs["td"] = []byte(`{"results": [{"id": "124", "content": "Gather figures", "parentId": "123"}]}`)
+			ExecuteDecisionsParallel([]Decision{
+				{TaskID: "123", Action: "decompose", Subtasks: []string{"Gather figures", "Draft summary"}},
+				{TaskID: "300", Action: "decompose", Subtasks: []string{"Gather figures"}},
+			})
+
+			Expect(mock.CalledCommands).To(ConsistOf(
+				[]string{"td", "task", "list", "--json", "--full"},
+				[]string{"td", "task", "add", "Draft summary", "--parent", "123"},
+				[]string{"td", "task", "add", "Gather figures", "--parent", "300"},
+			))
+		})
+
+		It("should defer a task by updating its due date", func() {
+			due := "2026-03-03"
+			ExecuteDecision(Decision{TaskID: "123", Action: "defer", Due: &due})
+			Expect(mock.CalledCommands).To(ContainElement([]string{"td", "task", "update", "123", "--due", "2026-03-03"}))
+		})
+
+		It("should truncate a decomposition to the subtask cap", func() {
+			subtasks := strings.Fields("alpha bravo charlie delta echo foxtrot golf hotel india juliet kilo lima mike november oscar papa quebec romeo sierra tango")
+			ExecuteDecision(Decision{TaskID: "123", Action: "decompose", Subtasks: subtasks})
+			Expect(mock.CalledCommands).To(HaveLen(9))
+			Expect(mock.CalledCommands[8]).To(ContainElement("hotel"))
+		})
+
+		It("should add a single plan subtask for an oversized decomposition when configured", func() {
+			Settings.PlanOnOverflow = true
+			subtasks := strings.Fields("alpha bravo charlie delta echo foxtrot golf hotel india juliet kilo lima mike november oscar papa quebec romeo sierra tango")
+			ExecuteDecision(Decision{TaskID: "123", Action: "decompose", Subtasks: subtasks, CurrentContent: "Renovate kitchen"})
+			Expect(mock.CalledCommands).To(ContainElement([]string{"td", "task", "add", "Plan: Renovate kitchen", "--parent", "123"}))
+			Expect(mock.CalledCommands).To(HaveLen(2))
+		})
 	})
 })
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEmptyOutput means the LLM answered without error but produced nothing.
+// Like a failed call it is retried while the retry budget lasts.
+var ErrEmptyOutput = errors.New("LLM returned empty output")
+
+// ContextLoadError is a failure to read or validate the phase 1 context.
+type ContextLoadError struct {
+	Path string
+	Err  error
+}
+
+func (e *ContextLoadError) Error() string {
+	path := e.Path
+	if path == "-" {
+		path = "stdin"
+	}
+	return fmt.Sprintf("load context from %s: %v", path, e.Err)
+}
+
+func (e *ContextLoadError) Unwrap() error { return e.Err }
+
+// TaskFetchError is a failure to read tasks from the task source.
+type TaskFetchError struct {
+	Source string
+	Err    error
+}
+
+func (e *TaskFetchError) Error() string {
+	return fmt.Sprintf("fetch tasks from %s: %v", e.Source, e.Err)
+}
+
+func (e *TaskFetchError) Unwrap() error { return e.Err }
+
+// LLMError is a failed LLM call, or a reply that couldn't be used, for one
+// task.
+type LLMError struct {
+	TaskID string
+	Err    error
+}
+
+func (e *LLMError) Error() string {
+	return fmt.Sprintf("LLM decision for task %s: %v", e.TaskID, e.Err)
+}
+
+func (e *LLMError) Unwrap() error { return e.Err }
+
+// ExecutionError is a task source command that failed while applying a
+// decision.
+type ExecutionError struct {
+	TaskID string
+	Action string
+	Err    error
+}
+
+func (e *ExecutionError) Error() string {
+	return fmt.Sprintf("%s task %s: %v", e.Action, e.TaskID, e.Err)
+}
+
+func (e *ExecutionError) Unwrap() error { return e.Err }
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Structured Errors", func() {
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+	})
+
+	It("should report a missing context file as a ContextLoadError", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "missing.json")
+		_, err := LoadContext(path)
+		var loadErr *ContextLoadError
+		Expect(errors.As(err, &loadErr)).To(BeTrue())
+		Expect(loadErr.Path).To(Equal(path))
+	})
+
+	It("should report a failing task source as a TaskFetchError", func() {
+		mock.Errors["td"] = exec.ErrNotFound
+		_, err := FetchAllTasks()
+		var fetchErr *TaskFetchError
+		Expect(errors.As(err, &fetchErr)).To(BeTrue())
+		Expect(fetchErr.Source).To(Equal("td"))
+		Expect(errors.Is(err, exec.ErrNotFound)).To(BeTrue())
+	})
+
+	It("should report LLM call and parse failures as LLMErrors", func() {
+		mock.Errors["openclaw"] = errors.New("gateway down")
+		decision, err := CallAgentForDecision(TaskContext{Task: Task{ID: "7"}}, nil)
+		var llmErr *LLMError
+		Expect(errors.As(err, &llmErr)).To(BeTrue())
+		Expect(llmErr.TaskID).To(Equal("7"))
+		Expect(decision.Action).To(Equal("skip"))
+
+		mock.Errors["openclaw"] = nil
+		mock.Outputs["openclaw"] = []byte("no JSON here")
+		_, err = CallAgentForDecision(TaskContext{Task: Task{ID: "7"}}, nil)
+		var parseErr *ParseError
+		Expect(errors.As(err, &llmErr)).To(BeTrue())
+		Expect(errors.As(err, &parseErr)).To(BeTrue())
+	})
+
+	It("should say so when the LLM returns empty output", func() {
+		mock.Outputs["openclaw"] = []byte{}
+		decision, err := CallAgentForDecision(TaskContext{Task: Task{ID: "7"}}, nil)
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Reasoning).To(Equal("LLM returned empty output"))
+		Expect(errors.Is(err, ErrEmptyOutput)).To(BeTrue())
+		var parseErr *ParseError
+		Expect(errors.As(err, &parseErr)).To(BeFalse())
+
+		Settings.LLMRetries = 1
+		mock.CalledCommands = nil
+		_, err = CallAgentForDecision(TaskContext{Task: Task{ID: "7"}}, NewRetryBudget(5))
+		Expect(errors.Is(err, ErrEmptyOutput)).To(BeTrue())
+		Expect(mock.CalledCommands).To(HaveLen(2))
+	})
+
+	It("should report failed commands as ExecutionErrors", func() {
+		mock.Errors["td"] = errors.New("exit status 1")
+		content := "Rewrite"
+		err := ExecuteDecision(Decision{TaskID: "3", Action: "recontextualize", NewContent: &content})
+		var execErr *ExecutionError
+		Expect(errors.As(err, &execErr)).To(BeTrue())
+		Expect(execErr.TaskID).To(Equal("3"))
+		Expect(execErr.Action).To(Equal("recontextualize"))
+
+		Expect(ExecuteDecision(Decision{TaskID: "3", Action: "skip"})).To(Succeed())
+	})
+})
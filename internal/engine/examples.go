@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxExamplesChars caps the few-shot block so a large examples file can't
+// crowd the task itself out of the prompt.
+const maxExamplesChars = 4000
+
+// Example is one task and the decision we'd want for it, shown to the LLM
+// as a few-shot example.
+type Example struct {
+	Task     string          `json:"task"`
+	Decision json.RawMessage `json:"decision"`
+}
+
+// Examples are rendered into every decision prompt; main loads them from
+// --examples.
+var Examples []Example
+
+// LoadExamples reads a JSON array of examples. An empty file yields none.
+func LoadExamples(path string) ([]Example, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read examples: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var examples []Example
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, fmt.Errorf("unmarshal examples: %w", err)
+	}
+	for i, ex := range examples {
+		var decision struct {
+			Action string `json:"action"`
+		}
+		if strings.TrimSpace(ex.Task) == "" {
+			return nil, fmt.Errorf("example %d: missing task", i)
+		}
+		if err := json.Unmarshal(ex.Decision, &decision); err != nil || decision.Action == "" {
+			return nil, fmt.Errorf("example %d: decision must be an object with an action", i)
+		}
+	}
+	return examples, nil
+}
+
+// RenderExamples formats examples as a prompt section, dropping those that
+// would take it past maxExamplesChars. It returns "" when there are none.
+func RenderExamples(examples []Example) string {
+	var sb strings.Builder
+	shown := 0
+	for _, ex := range examples {
+		var decision bytes.Buffer
+		if err := json.Compact(&decision, ex.Decision); err != nil {
+			continue
+		}
+		entry := fmt.Sprintf("- Task: %s\n  Decision: %s\n", ex.Task, decision.String())
+		if sb.Len()+len(entry) > maxExamplesChars {
+			break
+		}
+		sb.WriteString(entry)
+		shown++
+	}
+	if shown == 0 {
+		return ""
+	}
+	if omitted := len(examples) - shown; omitted > 0 {
+		sb.WriteString(fmt.Sprintf("- (%d more examples omitted)\n", omitted))
+	}
+	return "Examples of good decisions:\n" + sb.String() + "\n"
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Few-shot Examples", func() {
+	taskCtx := TaskContext{Task: Task{ID: "1", Content: "Learn the banjo"}}
+	writeExamples := func(content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "examples.json")
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		DeferCleanup(func() { Examples = nil })
+	})
+
+	It("should include loaded examples in the prompt before the instructions", func() {
+		examples, err := LoadExamples(writeExamples(`[
+			{"task": "Learn Rust", "decision": {"action": "decompose", "subtasks": ["Install rustup"], "reasoning": "stale"}}
+		]`))
+		Expect(err).NotTo(HaveOccurred())
+		Examples = examples
+
+		prompt := BuildDecisionPrompt(taskCtx)
+		Expect(prompt).To(ContainSubstring("Examples of good decisions:\n- Task: Learn Rust\n" +
+			`  Decision: {"action":"decompose","subtasks":["Install rustup"],"reasoning":"stale"}`))
+		Expect(strings.Index(prompt, "Examples of good decisions")).To(BeNumerically("<", strings.Index(prompt, "decide ONE action")))
+	})
+
+	It("should leave the prompt unchanged for an empty file", func() {
+		before := BuildDecisionPrompt(taskCtx)
+		examples, err := LoadExamples(writeExamples("\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(examples).To(BeEmpty())
+		Examples = examples
+		Expect(BuildDecisionPrompt(taskCtx)).To(Equal(before))
+	})
+
+	It("should reject an example without an action", func() {
+		_, err := LoadExamples(writeExamples(`[{"task": "Learn Rust", "decision": {"reasoning": "?"}}]`))
+		Expect(err).To(MatchError(ContainSubstring("example 0")))
+	})
+
+	It("should cap the rendered block", func() {
+		var examples []Example
+		for i := 0; i < 100; i++ {
+			examples = append(examples, Example{
+				Task:     fmt.Sprintf("Task %d %s", i, strings.Repeat("x", 80)),
+				Decision: []byte(`{"action": "skip", "reasoning": "fine as is"}`),
+			})
+		}
+		rendered := RenderExamples(examples)
+		Expect(len(rendered)).To(BeNumerically("<=", maxExamplesChars+100))
+		Expect(rendered).To(MatchRegexp(`\(\d+ more examples omitted\)`))
+	})
+})
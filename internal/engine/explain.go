@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Exchange is one prompt/response round trip with the LLM for a task.
+type Exchange struct {
+	TaskID   string
+	TraceID  string
+	Prompt   string
+	Response string
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// ExplainPath is where WriteExplain puts a task's artifact under dir, named
+// for the task and, when there is one, the trace ID. IDs are reduced to
+// filename-safe characters.
+func ExplainPath(dir, taskID, traceID string) string {
+	name := unsafeFileChars.ReplaceAllString(taskID, "_")
+	if traceID != "" {
+		name += "." + unsafeFileChars.ReplaceAllString(traceID, "_")
+	}
+	return filepath.Join(dir, name+".txt")
+}
+
+// WriteExplain saves the prompt and raw LLM response for one task, so a bad
+// decision can be traced back to what the model saw and said.
+func WriteExplain(dir string, exchange Exchange) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create explain dir: %w", err)
+	}
+	content := fmt.Sprintf("=== PROMPT ===\n%s\n\n=== RESPONSE ===\n%s\n", exchange.Prompt, exchange.Response)
+	if err := os.WriteFile(ExplainPath(dir, exchange.TaskID, exchange.TraceID), []byte(content), 0644); err != nil {
+		return fmt.Errorf("write explain artifact: %w", err)
+	}
+	return nil
+}
+
+// ReplayBackend answers each prompt with the response recorded for it in an
+// --explain directory, so a deterministic run can be reproduced without the
+// LLM.
+type ReplayBackend struct {
+	responses map[string]string
+}
+
+// LoadReplayBackend indexes every artifact WriteExplain left in dir.
+func LoadReplayBackend(dir string) (*ReplayBackend, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("list explain artifacts: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no explain artifacts in %s", dir)
+	}
+	b := &ReplayBackend{responses: make(map[string]string, len(paths))}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read explain artifact: %w", err)
+		}
+		prompt, response, ok := parseExplain(string(data))
+		if !ok {
+			return nil, fmt.Errorf("%s is not an explain artifact", path)
+		}
+		b.responses[prompt] = response
+	}
+	return b, nil
+}
+
+func (b *ReplayBackend) Decide(prompt string) (string, error) {
+	response, ok := b.responses[prompt]
+	if !ok {
+		return "", fmt.Errorf("no recorded response for this prompt")
+	}
+	return response, nil
+}
+
+// parseExplain splits an artifact written by WriteExplain back into its
+// prompt and response.
+func parseExplain(content string) (prompt, response string, ok bool) {
+	const promptHeader, responseHeader = "=== PROMPT ===\n", "\n\n=== RESPONSE ===\n"
+	if !strings.HasPrefix(content, promptHeader) {
+		return "", "", false
+	}
+	prompt, response, ok = strings.Cut(strings.TrimPrefix(content, promptHeader), responseHeader)
+	return prompt, strings.TrimSuffix(response, "\n"), ok
+}
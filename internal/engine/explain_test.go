@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Explain Artifacts", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Settings.Explain = dir
+		CommandRunner = &MockRunner{
+			Outputs: map[string][]byte{"openclaw": []byte(`Thinking... {"action": "skip", "reasoning": "fine"}`)},
+			Errors:  map[string]error{},
+		}
+	})
+
+	It("should write the exact prompt and raw response for each task", func() {
+		previous := NewTraceID
+		NewTraceID = func() string { return "trace" }
+		DeferCleanup(func() { NewTraceID = previous })
+		ctx := &InertiaContext{State: State{Energy: "low"}}
+		task := Task{ID: "42", Content: "Call the bank"}
+		ProcessTask(task, ctx)
+
+		data, err := os.ReadFile(filepath.Join(dir, "42.trace.txt"))
+		Expect(err).NotTo(HaveOccurred())
+		prompt, response, found := strings.Cut(string(data), "\n\n=== RESPONSE ===\n")
+		Expect(found).To(BeTrue())
+		Expect(strings.TrimPrefix(prompt, "=== PROMPT ===\n")).To(Equal(BuildDecisionPrompt(ContextualizeTask(task, ctx))))
+		Expect(response).To(ContainSubstring("Thinking..."))
+	})
+
+	It("should replay recorded responses into identical decisions", func() {
+		Settings.Deterministic = true
+		ctx := &InertiaContext{State: State{Energy: "low"}}
+		tasks := []Task{{ID: "2", Content: "Call the bank"}, {ID: "1", Content: "Water plants"}}
+		recorded := ProcessTasksParallel(tasks, ctx, 2)
+
+		replay, err := LoadReplayBackend(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Backend = replay
+		DeferCleanup(func() { Backend = defaultBackend() })
+		CommandRunner = &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		Expect(ProcessTasksParallel(tasks, ctx, 2)).To(Equal(recorded))
+
+		_, err = replay.Decide("a prompt never recorded")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should derive safe file names from task IDs", func() {
+		Expect(ExplainPath(dir, "../../etc/passwd", "")).To(Equal(filepath.Join(dir, "______etc_passwd.txt")))
+	})
+
+	It("should carry one trace ID from the decision to the artifact's name", func() {
+		n := 0
+		previous := NewTraceID
+		NewTraceID = func() string {
+			n++
+			return fmt.Sprintf("t%d", n)
+		}
+		DeferCleanup(func() { NewTraceID = previous })
+
+		first := ProcessTask(Task{ID: "42", Content: "Call the bank"}, &InertiaContext{})
+		second := ProcessTask(Task{ID: "42", Content: "Call the bank"}, &InertiaContext{})
+		Expect(first.TraceID).To(Equal("t1"))
+		Expect(second.TraceID).To(Equal("t2"))
+		Expect(ExplainPath(dir, "42", first.TraceID)).To(BeAnExistingFile())
+		Expect(ExplainPath(dir, "42", second.TraceID)).To(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,317 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// downgradeToSkip turns an actionable decision into a skip, keeping the
+// original reasoning so the report still explains what the LLM wanted.
+func downgradeToSkip(d Decision, note string) Decision {
+	d.Reasoning = fmt.Sprintf("%s (was %s: %s)", note, d.Action, d.Reasoning)
+	d.Action = "skip"
+	d.Priority = nil
+	d.NewContent = nil
+	d.Subtasks = nil
+	d.Due = nil
+	d.Actions = nil
+	return d
+}
+
+// ApplyScoreThreshold downgrades decisions scoring below min to skip. Ice-box
+// decisions on tasks older than Settings.IceBoxAgeDays pass regardless, since
+// a low score is exactly why an old task gets ice-boxed.
+func ApplyScoreThreshold(decisions []Decision, min float64) []Decision {
+	result := make([]Decision, len(decisions))
+	for i, d := range decisions {
+		suppress := d.Action != "skip" && d.Action != ActionFiltered && d.InertiaScore < min
+		if suppress && d.Action == "ice-box" && d.AgeDays > Settings.IceBoxAgeDays {
+			suppress = false
+		}
+		if suppress {
+			d = downgradeToSkip(d, fmt.Sprintf("inertia score %.1f below threshold %.1f", d.InertiaScore, min))
+		}
+		result[i] = d
+	}
+	return result
+}
+
+// ApplyAgeThresholds downgrades decompose and ice-box decisions on tasks no
+// older than the thresholds the prompt states, in case the model ignores
+// them. A combined decision only loses the offending actions.
+func ApplyAgeThresholds(decisions []Decision, decomposeAgeDays, iceBoxAgeDays int) []Decision {
+	result := make([]Decision, len(decisions))
+	for i, d := range decisions {
+		result[i] = governSubActions(d, func(d Decision) Decision {
+			switch {
+			case d.Action == "decompose" && d.AgeDays <= decomposeAgeDays:
+				return downgradeToSkip(d, fmt.Sprintf("task is %d days old, decompose needs more than %d", d.AgeDays, decomposeAgeDays))
+			case d.Action == "ice-box" && d.AgeDays <= iceBoxAgeDays:
+				return downgradeToSkip(d, fmt.Sprintf("task is %d days old, ice-box needs more than %d", d.AgeDays, iceBoxAgeDays))
+			}
+			return d
+		})
+	}
+	return result
+}
+
+// FilterExecutableActions downgrades decisions whose action is not in
+// allowed to skip. An empty allowlist permits every action.
+func FilterExecutableActions(decisions []Decision, allowed []string) []Decision {
+	if len(allowed) == 0 {
+		return decisions
+	}
+	permitted := make(map[string]bool, len(allowed))
+	for _, action := range allowed {
+		permitted[action] = true
+	}
+	result := make([]Decision, len(decisions))
+	for i, d := range decisions {
+		if len(d.Actions) > 0 {
+			// Keep whichever of a combined decision's actions are allowed.
+			var subs []SubAction
+			for _, sub := range d.Actions {
+				if permitted[sub.Action] {
+					subs = append(subs, sub)
+				}
+			}
+			if len(subs) > 0 {
+				d = withSubActions(d, subs)
+			}
+		}
+		if d.Action != "skip" && d.Action != ActionFiltered && !permitted[d.Action] {
+			d = downgradeToSkip(d, fmt.Sprintf("action not in --allow-actions %s", strings.Join(allowed, ",")))
+		}
+		result[i] = d
+	}
+	return result
+}
+
+// CapDecisionsPerProject limits the number of actionable decisions in any one
+// project to max, keeping those with the highest inertia scores and
+// downgrading the rest to skip. A max of zero or less disables the cap.
+func CapDecisionsPerProject(decisions []Decision, tasks []Task, max int) []Decision {
+	if max <= 0 {
+		return decisions
+	}
+	projectOf := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		projectOf[task.ID] = task.ProjectID
+	}
+
+	byProject := make(map[string][]int)
+	for i, d := range decisions {
+		if d.Action == "skip" || d.Action == ActionFiltered {
+			continue
+		}
+		project := projectOf[d.TaskID]
+		byProject[project] = append(byProject[project], i)
+	}
+
+	capped := make([]Decision, len(decisions))
+	copy(capped, decisions)
+	for project, indexes := range byProject {
+		if len(indexes) <= max {
+			continue
+		}
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return decisions[indexes[a]].InertiaScore > decisions[indexes[b]].InertiaScore
+		})
+		for _, i := range indexes[max:] {
+			capped[i] = downgradeToSkip(decisions[i], fmt.Sprintf("project %s over per-run cap of %d", project, max))
+		}
+	}
+	return capped
+}
+
+// CapTotalMutations is a safety net against a bad prompt rewriting the
+// whole backlog: it lets at most max actionable decisions through, keeping
+// those with the highest inertia scores and downgrading the rest to skip. A
+// max of zero or less disables the cap.
+func CapTotalMutations(decisions []Decision, max int) []Decision {
+	if max <= 0 {
+		return decisions
+	}
+	var actionable []int
+	for i, d := range decisions {
+		if d.Action != "skip" && d.Action != ActionFiltered {
+			actionable = append(actionable, i)
+		}
+	}
+	if len(actionable) <= max {
+		return decisions
+	}
+	sort.SliceStable(actionable, func(a, b int) bool {
+		return decisions[actionable[a]].InertiaScore > decisions[actionable[b]].InertiaScore
+	})
+	capped := make([]Decision, len(decisions))
+	copy(capped, decisions)
+	for _, i := range actionable[max:] {
+		capped[i] = downgradeToSkip(decisions[i], fmt.Sprintf("over per-run cap of %d mutations", max))
+	}
+	Log.Warn("%d actions exceed --max-mutations %d: keeping the %d highest-scoring, skipping the rest", len(actionable), max, max)
+	return capped
+}
+
+// ReconcileDecisions enforces constraints no single decision can see, using
+// ctxs (matched by task ID) for each task's project and historical weight:
+//   - at most Settings.MaxUrgentPerProject reprioritizations to the most
+//     urgent level per project, the lower-ranked ones demoted a level;
+//   - with Settings.DedupeRewrites, recontextualizations that rewrite
+//     different tasks to the same text are reduced to the top-ranked one.
+//
+// Decisions rank by inertia score, then historical weight. Combined
+// decisions count by their reprioritize or recontextualize action, and only
+// that action is demoted or dropped.
+func ReconcileDecisions(decisions []Decision, ctxs []TaskContext) []Decision {
+	byID := make(map[string]TaskContext, len(ctxs))
+	for _, c := range ctxs {
+		byID[c.Task.ID] = c
+	}
+	ranked := func(indexes []int) {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			da, db := decisions[indexes[a]], decisions[indexes[b]]
+			if da.InertiaScore != db.InertiaScore {
+				return da.InertiaScore > db.InertiaScore
+			}
+			return byID[da.TaskID].HistoricalWeight > byID[db.TaskID].HistoricalWeight
+		})
+	}
+
+	result := make([]Decision, len(decisions))
+	copy(result, decisions)
+
+	if max := Settings.MaxUrgentPerProject; max > 0 {
+		urgent, demoted := 1, 2
+		if Settings.PriorityScale == PriorityAscending {
+			urgent, demoted = 4, 3
+		}
+		var projects []string
+		byProject := make(map[string][]int)
+		for i, d := range decisions {
+			if !hasAction(d, "reprioritize") || d.Priority == nil || *d.Priority != urgent {
+				continue
+			}
+			project := byID[d.TaskID].Task.ProjectID
+			if _, ok := byProject[project]; !ok {
+				projects = append(projects, project)
+			}
+			byProject[project] = append(byProject[project], i)
+		}
+		for _, project := range projects {
+			indexes := byProject[project]
+			if len(indexes) <= max {
+				continue
+			}
+			ranked(indexes)
+			for _, i := range indexes[max:] {
+				result[i] = governSubActions(decisions[i], func(d Decision) Decision {
+					if d.Action != "reprioritize" {
+						return d
+					}
+					p := demoted
+					d.Priority = &p
+					d.Reasoning = fmt.Sprintf("demoted to %s: project %s over cap of %d %s tasks (was: %s)",
+						NormalizePriority(demoted, Settings.PriorityScale), project, max, NormalizePriority(urgent, Settings.PriorityScale), d.Reasoning)
+					return d
+				})
+			}
+		}
+	}
+
+	if Settings.DedupeRewrites {
+		var rewrites []string
+		byRewrite := make(map[string][]int)
+		for i, d := range decisions {
+			if !hasAction(d, "recontextualize") || d.NewContent == nil {
+				continue
+			}
+			key := normalizeText(*d.NewContent)
+			if _, ok := byRewrite[key]; !ok {
+				rewrites = append(rewrites, key)
+			}
+			byRewrite[key] = append(byRewrite[key], i)
+		}
+		for _, key := range rewrites {
+			indexes := byRewrite[key]
+			if len(indexes) < 2 {
+				continue
+			}
+			ranked(indexes)
+			kept := decisions[indexes[0]].TaskID
+			for _, i := range indexes[1:] {
+				result[i] = governSubActions(result[i], func(d Decision) Decision {
+					if d.Action != "recontextualize" {
+						return d
+					}
+					return downgradeToSkip(d, fmt.Sprintf("same rewrite as task %s", kept))
+				})
+			}
+		}
+	}
+	return result
+}
+
+// labelOverride finds the action task's labels force under rules, matching
+// labels case-insensitively. A skip rule wins over any other; otherwise the
+// first matching label decides. Rules with an empty action are disabled.
+func labelOverride(task Task, rules map[string]string) (label, action string) {
+	for _, l := range task.Labels {
+		forced := rules[l]
+		for rule, a := range rules {
+			if forced == "" && strings.EqualFold(rule, l) {
+				forced = a
+			}
+		}
+		if forced == "" {
+			continue
+		}
+		if forced == "skip" {
+			return l, forced
+		}
+		if action == "" {
+			label, action = l, forced
+		}
+	}
+	return label, action
+}
+
+// ApplyLabelOverrides forces the action a task's labels call for, whatever
+// the LLM decided, e.g. "pinned" → skip. Rules map labels to actions. A
+// forced action the decision can't carry out, such as decompose without
+// subtasks, becomes a skip instead. Of a combined decision that includes
+// the forced action, only that action is kept.
+func ApplyLabelOverrides(decisions []Decision, tasks []Task, rules map[string]string) []Decision {
+	if len(rules) == 0 {
+		return decisions
+	}
+	taskByID := make(map[string]Task, len(tasks))
+	for _, task := range tasks {
+		taskByID[task.ID] = task
+	}
+	result := make([]Decision, len(decisions))
+	for i, d := range decisions {
+		label, forced := labelOverride(taskByID[d.TaskID], rules)
+		switch {
+		case forced == "" || d.Action == ActionFiltered || d.Action == forced && len(d.Actions) == 0:
+		case hasAction(d, forced):
+			// Of a combined decision, keep only the forced action.
+			d = governSubActions(d, func(d Decision) Decision {
+				if d.Action == forced {
+					return d
+				}
+				return downgradeToSkip(d, fmt.Sprintf("label %q forces %s", label, forced))
+			})
+		case forced == "skip":
+			d = downgradeToSkip(d, fmt.Sprintf("label %q forces skip", label))
+		case forced == "ice-box":
+			d = downgradeToSkip(d, fmt.Sprintf("label %q forces ice-box", label))
+			d.Action = "ice-box"
+		default:
+			d = downgradeToSkip(d, fmt.Sprintf("label %q forces %s, which the LLM did not propose", label, forced))
+		}
+		result[i] = d
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+package engine
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decision Governors", func() {
+	Describe("CapDecisionsPerProject", func() {
+		It("should keep only the top-scoring decisions within a project", func() {
+			tasks := []Task{
+				{ID: "a", ProjectID: "work"},
+				{ID: "b", ProjectID: "work"},
+				{ID: "c", ProjectID: "work"},
+				{ID: "d", ProjectID: "home"},
+			}
+			decisions := []Decision{
+				{TaskID: "a", Action: "reprioritize", InertiaScore: 3},
+				{TaskID: "b", Action: "decompose", InertiaScore: 9},
+				{TaskID: "c", Action: "recontextualize", InertiaScore: 7},
+				{TaskID: "d", Action: "reprioritize", InertiaScore: 1},
+			}
+
+			capped := CapDecisionsPerProject(decisions, tasks, 2)
+			Expect(capped).To(HaveLen(4))
+			Expect(capped[0].Action).To(Equal("skip"))
+			Expect(capped[0].Reasoning).To(ContainSubstring("cap"))
+			Expect(capped[1].Action).To(Equal("decompose"))
+			Expect(capped[2].Action).To(Equal("recontextualize"))
+			Expect(capped[3].Action).To(Equal("reprioritize"))
+		})
+
+		It("should not count skips against the cap", func() {
+			tasks := []Task{{ID: "a", ProjectID: "work"}, {ID: "b", ProjectID: "work"}}
+			decisions := []Decision{
+				{TaskID: "a", Action: "skip", InertiaScore: 9},
+				{TaskID: "b", Action: "reprioritize", InertiaScore: 1},
+			}
+
+			capped := CapDecisionsPerProject(decisions, tasks, 1)
+			Expect(capped[1].Action).To(Equal("reprioritize"))
+		})
+	})
+
+	Describe("CapTotalMutations", func() {
+		It("should keep only the three highest-scoring of ten actions", func() {
+			scores := []float64{4, 9, 1, 7, 3, 8, 2, 6, 5, 0}
+			var decisions []Decision
+			for i, score := range scores {
+				decisions = append(decisions, Decision{TaskID: fmt.Sprint(i), Action: "reprioritize", InertiaScore: score})
+			}
+
+			capped := CapTotalMutations(decisions, 3)
+			var kept []string
+			for _, d := range capped {
+				if d.Action != "skip" {
+					kept = append(kept, d.TaskID)
+				}
+			}
+			Expect(kept).To(Equal([]string{"1", "3", "5"}))
+			Expect(capped[0].Reasoning).To(ContainSubstring("cap of 3 mutations"))
+			Expect(decisions[0].Action).To(Equal("reprioritize"))
+		})
+
+		It("should leave decisions alone when disabled", func() {
+			decisions := []Decision{{TaskID: "a", Action: "decompose"}, {TaskID: "b", Action: "reprioritize"}}
+			Expect(CapTotalMutations(decisions, 0)).To(Equal(decisions))
+		})
+	})
+
+	Describe("ApplyScoreThreshold", func() {
+		It("should suppress low-scoring actions and pass high-scoring ones", func() {
+			low, high := 2, 1
+			decisions := []Decision{
+				{TaskID: "a", Action: "reprioritize", Priority: &low, InertiaScore: 2},
+				{TaskID: "b", Action: "reprioritize", Priority: &high, InertiaScore: 8},
+			}
+
+			result := ApplyScoreThreshold(decisions, 5)
+			Expect(result[0].Action).To(Equal("skip"))
+			Expect(result[0].Priority).To(BeNil())
+			Expect(result[0].Reasoning).To(ContainSubstring("below threshold"))
+			Expect(result[1].Action).To(Equal("reprioritize"))
+		})
+
+		It("should let ice-box through for very old tasks", func() {
+			decisions := []Decision{
+				{TaskID: "old", Action: "ice-box", InertiaScore: 1, AgeDays: 200},
+				{TaskID: "new", Action: "ice-box", InertiaScore: 1, AgeDays: 5},
+			}
+
+			result := ApplyScoreThreshold(decisions, 5)
+			Expect(result[0].Action).To(Equal("ice-box"))
+			Expect(result[1].Action).To(Equal("skip"))
+		})
+	})
+
+	Describe("FilterExecutableActions", func() {
+		It("should hold back actions outside the allowlist", func() {
+			mock := &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+			CommandRunner = mock
+			cfg, err := ParseFlags([]string{"--allow-actions", "reprioritize"})
+			Expect(err).NotTo(HaveOccurred())
+
+			priority := 1
+			decisions := FilterExecutableActions([]Decision{
+				{TaskID: "a", Action: "decompose", Subtasks: []string{"step one"}},
+				{TaskID: "b", Action: "reprioritize", Priority: &priority},
+			}, cfg.AllowActions)
+			ExecuteDecisionsParallel(decisions)
+
+			Expect(decisions[0].Action).To(Equal("skip"))
+			Expect(decisions[0].Reasoning).To(ContainSubstring("--allow-actions"))
+			Expect(mock.CalledCommands).To(Equal([][]string{{"td", "task", "update", "b", "--priority", "p1"}}))
+		})
+
+		It("should allow every action when the list is empty", func() {
+			decisions := []Decision{{TaskID: "a", Action: "decompose"}}
+			Expect(FilterExecutableActions(decisions, nil)).To(Equal(decisions))
+		})
+	})
+
+	Describe("ApplyAgeThresholds", func() {
+		It("should suppress a decompose on a task younger than the threshold", func() {
+			decisions := ApplyAgeThresholds([]Decision{
+				{TaskID: "young", Action: "decompose", Subtasks: []string{"a", "b"}, AgeDays: 10},
+				{TaskID: "old", Action: "decompose", Subtasks: []string{"a", "b"}, AgeDays: 20},
+			}, 14, 30)
+
+			Expect(decisions[0].Action).To(Equal("skip"))
+			Expect(decisions[0].Subtasks).To(BeNil())
+			Expect(decisions[0].Reasoning).To(ContainSubstring("10 days old"))
+			Expect(decisions[1].Action).To(Equal("decompose"))
+		})
+
+		It("should suppress an ice-box on a task younger than the threshold", func() {
+			decisions := ApplyAgeThresholds([]Decision{
+				{TaskID: "young", Action: "ice-box", AgeDays: 20},
+				{TaskID: "old", Action: "ice-box", AgeDays: 45},
+			}, 14, 30)
+
+			Expect(decisions[0].Action).To(Equal("skip"))
+			Expect(decisions[1].Action).To(Equal("ice-box"))
+		})
+	})
+})
+
+var _ = Describe("Cross-task Reconciliation", func() {
+	p := func(n int) *int { return &n }
+	ctxs := []TaskContext{
+		{Task: Task{ID: "a", ProjectID: "work"}, HistoricalWeight: 2},
+		{Task: Task{ID: "b", ProjectID: "work"}, HistoricalWeight: 5},
+		{Task: Task{ID: "c", ProjectID: "work"}, HistoricalWeight: 9},
+		{Task: Task{ID: "d", ProjectID: "home"}},
+	}
+
+	It("should keep only the highest-inertia p1 per project under a cap", func() {
+		Settings.MaxUrgentPerProject = 1
+		decisions := []Decision{
+			{TaskID: "a", Action: "reprioritize", Priority: p(1), InertiaScore: 6, Reasoning: "due soon"},
+			{TaskID: "b", Action: "reprioritize", Priority: p(1), InertiaScore: 8},
+			{TaskID: "c", Action: "reprioritize", Priority: p(1), InertiaScore: 6},
+			{TaskID: "d", Action: "reprioritize", Priority: p(1), InertiaScore: 1},
+		}
+
+		reconciled := ReconcileDecisions(decisions, ctxs)
+		Expect(*reconciled[1].Priority).To(Equal(1))
+		Expect(*reconciled[3].Priority).To(Equal(1))
+		Expect(*reconciled[0].Priority).To(Equal(2))
+		Expect(*reconciled[2].Priority).To(Equal(2))
+		Expect(reconciled[0].Reasoning).To(Equal("demoted to p2: project work over cap of 1 p1 tasks (was: due soon)"))
+		Expect(*decisions[0].Priority).To(Equal(1))
+	})
+
+	It("should leave urgent reprioritizations alone without a cap", func() {
+		decisions := []Decision{
+			{TaskID: "a", Action: "reprioritize", Priority: p(1)},
+			{TaskID: "b", Action: "reprioritize", Priority: p(1)},
+		}
+		Expect(ReconcileDecisions(decisions, ctxs)).To(Equal(decisions))
+	})
+
+	It("should keep one of several tasks rewritten to the same text, breaking ties by historical weight", func() {
+		rewrite := "Email Dana the draft"
+		same := "email dana  the draft"
+		decisions := []Decision{
+			{TaskID: "a", Action: "recontextualize", NewContent: &rewrite, InertiaScore: 5},
+			{TaskID: "c", Action: "recontextualize", NewContent: &same, InertiaScore: 5},
+		}
+
+		reconciled := ReconcileDecisions(decisions, ctxs)
+		Expect(reconciled[0].Action).To(Equal("skip"))
+		Expect(reconciled[0].Reasoning).To(HavePrefix("same rewrite as task c"))
+		Expect(reconciled[1].Action).To(Equal("recontextualize"))
+
+		Settings.DedupeRewrites = false
+		Expect(ReconcileDecisions(decisions, ctxs)).To(Equal(decisions))
+	})
+})
+
+var _ = Describe("Label Overrides", func() {
+	p := func(n int) *int { return &n }
+	tasks := []Task{
+		{ID: "1", Labels: []string{"NoAuto"}},
+		{ID: "2", Labels: []string{"breakdown"}},
+		{ID: "3", Labels: []string{"breakdown"}},
+		{ID: "4", Labels: []string{"someday"}},
+		{ID: "5"},
+	}
+	rules := map[string]string{"noauto": "skip", "breakdown": "decompose", "someday": "ice-box"}
+
+	It("should force the labelled action whatever the LLM decided", func() {
+		decisions := []Decision{
+			{TaskID: "1", Action: "reprioritize", Priority: p(1), Reasoning: "due soon"},
+			{TaskID: "2", Action: "decompose", Subtasks: []string{"Outline"}},
+			{TaskID: "3", Action: "reprioritize", Priority: p(2)},
+			{TaskID: "4", Action: "recontextualize"},
+			{TaskID: "5", Action: "reprioritize", Priority: p(3)},
+		}
+
+		overridden := ApplyLabelOverrides(decisions, tasks, rules)
+		Expect(overridden[0].Action).To(Equal("skip"))
+		Expect(overridden[0].Priority).To(BeNil())
+		Expect(overridden[0].Reasoning).To(Equal(`label "NoAuto" forces skip (was reprioritize: due soon)`))
+		Expect(overridden[1]).To(Equal(decisions[1]))
+		Expect(overridden[2].Action).To(Equal("skip"))
+		Expect(overridden[2].Reasoning).To(HavePrefix(`label "breakdown" forces decompose, which the LLM did not propose`))
+		Expect(overridden[3].Action).To(Equal("ice-box"))
+		Expect(overridden[4]).To(Equal(decisions[4]))
+	})
+
+	It("should skip pinned and noauto tasks by default", func() {
+		decisions := []Decision{{TaskID: "1", Action: "reprioritize", Priority: p(1)}}
+		Expect(ApplyLabelOverrides(decisions, tasks, Settings.LabelOverrides)[0].Action).To(Equal("skip"))
+	})
+
+	It("should keep a pinned duplicate from being merged away", func() {
+		duplicates := []Task{{ID: "6", Content: "Renew passport"}, {ID: "7", Content: "Renew passport", Labels: []string{"pinned"}}}
+		merges := MergeDecisions(FindDuplicateTasks(duplicates, 0.9), duplicates)
+		Expect(merges).To(HaveLen(1))
+
+		overridden := ApplyLabelOverrides(merges, append(tasks, duplicates...), Settings.LabelOverrides)
+		Expect(overridden[0].TaskID).To(Equal("7"))
+		Expect(overridden[0].Action).To(Equal("skip"))
+		Expect(overridden[0].Reasoning).To(HavePrefix(`label "pinned" forces skip`))
+	})
+
+	It("should tell the LLM which action a label forces", func() {
+		Settings.LabelOverrides = rules
+		Expect(BuildDecisionPrompt(TaskContext{Task: tasks[1]})).To(ContainSubstring(`This task is labelled "breakdown": you must choose "decompose".`))
+		Expect(BuildDecisionPrompt(TaskContext{Task: tasks[0]})).NotTo(ContainSubstring("you must choose"))
+	})
+})
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IceBoxOptions are the thresholds for a deterministic ice-box sweep.
+type IceBoxOptions struct {
+	// MinAgeDays is the age a task must exceed to be swept.
+	MinAgeDays int
+	// MaxWeight is the historical weight at or above which a task is kept.
+	MaxWeight float64
+}
+
+// SelectForIceBox picks, without asking the LLM, the tasks old enough and
+// weakly enough rooted in the gazetteer to ice-box. Tasks with an unknown
+// creation date and tasks serving one of today's intentions are kept.
+func SelectForIceBox(tasks []Task, ctx *InertiaContext, opts IceBoxOptions) []Decision {
+	var decisions []Decision
+	for _, task := range tasks {
+		if task.AddedAt.IsZero() {
+			continue
+		}
+		taskCtx := ContextualizeTask(task, ctx)
+		if taskCtx.AgeDays <= opts.MinAgeDays || taskCtx.HistoricalWeight >= opts.MaxWeight {
+			continue
+		}
+		if taskCtx.IntentionAlignment > neutralAlignment {
+			continue
+		}
+		decisions = append(decisions, withFetchedValues(Decision{
+			TaskID:       task.ID,
+			Action:       "ice-box",
+			Reasoning:    fmt.Sprintf("%d days old with historical weight %.1f (below %.1f)", taskCtx.AgeDays, taskCtx.HistoricalWeight, opts.MaxWeight),
+			InertiaScore: ComputeInertiaScore(taskCtx, Settings.Weights),
+		}, task))
+	}
+	return decisions
+}
+
+// IceBoxStrategy decides how a task is put in the ice-box.
+type IceBoxStrategy interface {
+	// Commands returns the argv lists that ice-box decision's task.
+	Commands(decision Decision) [][]string
+}
+
+// IceBox is the active strategy; main replaces it according to
+// --icebox-strategy.
+var IceBox IceBoxStrategy = ProjectIceBox{}
+
+// ProjectIceBox moves ice-boxed tasks into a dedicated project. Without a
+// resolved project ID it leaves them where they are.
+type ProjectIceBox struct {
+	ProjectID string
+}
+
+func (s ProjectIceBox) Commands(decision Decision) [][]string {
+	if s.ProjectID == "" {
+		Log.Warn("No ice-box project resolved, leaving task %s in place", decision.TaskID)
+		return nil
+	}
+	return [][]string{Source.ProjectCommand(decision.TaskID, s.ProjectID)}
+}
+
+// LabelIceBox tags ice-boxed tasks with a label instead of moving them,
+// keeping the labels they had when fetched.
+type LabelIceBox struct {
+	Label string
+}
+
+func (s LabelIceBox) Commands(decision Decision) [][]string {
+	labels := slices.Clone(decision.CurrentLabels)
+	if !slices.Contains(labels, s.Label) {
+		labels = append(labels, s.Label)
+	}
+	return [][]string{Source.LabelsCommand(decision.TaskID, labels)}
+}
+
+// recordIceBoxUndo records what the active strategy changed in ice-boxing
+// decision's task, so --undo can put it back.
+func recordIceBoxUndo(decision Decision) {
+	switch s := IceBox.(type) {
+	case ProjectIceBox:
+		if s.ProjectID != "" {
+			recordUndo(decision, UndoFieldProject, decision.CurrentProjectID, localProjectID(s.ProjectID, decision.SourceID))
+		}
+	case LabelIceBox:
+		recordUndo(decision, UndoFieldLabels, strings.Join(decision.CurrentLabels, ","), s.Label)
+	}
+}
+
+// NewIceBoxStrategy builds the strategy named by --icebox-strategy.
+func NewIceBoxStrategy(name, projectID, label string) (IceBoxStrategy, error) {
+	switch name {
+	case "", "project":
+		return ProjectIceBox{ProjectID: projectID}, nil
+	case "label":
+		if label == "" {
+			return nil, fmt.Errorf("--icebox-strategy label needs --icebox-label")
+		}
+		return LabelIceBox{Label: label}, nil
+	}
+	return nil, fmt.Errorf("unknown ice-box strategy %q (want project or label)", name)
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ice-box Sweep", func() {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	opts := IceBoxOptions{MinAgeDays: 30, MaxWeight: 2}
+	ctx := &InertiaContext{
+		Gazetteer: Gazetteer{Concepts: []Entity{{Name: "Guitar", SpanYears: json.RawMessage(`10`)}}},
+	}
+
+	BeforeEach(func() {
+		NowFunc = func() time.Time { return now }
+		DeferCleanup(func() { NowFunc = time.Now })
+	})
+
+	ids := func(decisions []Decision) []string {
+		var out []string
+		for _, d := range decisions {
+			out = append(out, d.TaskID)
+		}
+		return out
+	}
+
+	It("should select only tasks older than the age threshold", func() {
+		tasks := []Task{
+			{ID: "old", Content: "Sort the attic", AddedAt: daysAgo(100)},
+			{ID: "young", Content: "Sort the shed", AddedAt: daysAgo(10)},
+			{ID: "edge", Content: "Sort the loft", AddedAt: daysAgo(30)},
+			{ID: "unknown", Content: "Sort the cellar"},
+		}
+		decisions := SelectForIceBox(tasks, ctx, opts)
+		Expect(ids(decisions)).To(Equal([]string{"old"}))
+		Expect(decisions[0].Action).To(Equal("ice-box"))
+		Expect(decisions[0].AgeDays).To(Equal(100))
+		Expect(decisions[0].Reasoning).To(Equal("100 days old with historical weight 0.0 (below 2.0)"))
+	})
+
+	It("should keep old tasks with enough historical weight", func() {
+		tasks := []Task{
+			{ID: "rooted", Content: "Restring guitar", AddedAt: daysAgo(100)},
+			{ID: "rootless", Content: "Restring racket", AddedAt: daysAgo(100)},
+		}
+		Expect(ids(SelectForIceBox(tasks, ctx, opts))).To(Equal([]string{"rootless"}))
+		Expect(ids(SelectForIceBox(tasks, ctx, IceBoxOptions{MinAgeDays: 30, MaxWeight: 20}))).To(Equal([]string{"rooted", "rootless"}))
+	})
+
+	It("should keep old tasks that serve today's intentions", func() {
+		withIntentions := &InertiaContext{Intentions: Intentions{Explicit: []string{"restring racket"}}}
+		tasks := []Task{{ID: "aligned", Content: "Restring racket", AddedAt: daysAgo(100)}}
+		Expect(SelectForIceBox(tasks, withIntentions, opts)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Ice-box Strategies", func() {
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		DeferCleanup(func() { IceBox = ProjectIceBox{} })
+	})
+
+	It("should move the task to the ice-box project with the project strategy", func() {
+		IceBox = ProjectIceBox{ProjectID: "proj-ice"}
+		ExecuteDecision(Decision{TaskID: "123", Action: "ice-box"})
+		Expect(mock.CalledCommands).To(Equal([][]string{{"td", "task", "update", "123", "--project", "proj-ice"}}))
+	})
+
+	It("should label the task with the label strategy", func() {
+		IceBox = LabelIceBox{Label: "icebox"}
+		ExecuteDecision(Decision{TaskID: "123", Action: "ice-box"})
+		Expect(mock.CalledCommands).To(Equal([][]string{{"td", "task", "update", "123", "--labels", "icebox"}}))
+	})
+
+	It("should keep the task's existing labels with the label strategy", func() {
+		IceBox = LabelIceBox{Label: "icebox"}
+		UndoRecorder = &UndoLog{}
+		DeferCleanup(func() { UndoRecorder = nil })
+		ExecuteDecision(Decision{TaskID: "123", Action: "ice-box", CurrentLabels: []string{"home", "errand"}})
+		Expect(mock.CalledCommands).To(Equal([][]string{{"td", "task", "update", "123", "--labels", "home,errand,icebox"}}))
+		Expect(UndoRecorder.Entries[0].OldValue).To(Equal("home,errand"))
+		Expect(BuildUndoCommands(UndoRecorder.Entries)).To(Equal([][]string{{"td", "task", "update", "123", "--labels", "home,errand"}}))
+	})
+
+	It("should ice-box merged duplicates the same way", func() {
+		IceBox = LabelIceBox{Label: "icebox"}
+		ExecuteDecision(Decision{TaskID: "456", Action: ActionMerge, Reasoning: "duplicate of 123"})
+		Expect(mock.CalledCommands).To(Equal([][]string{{"td", "task", "update", "456", "--labels", "icebox"}}))
+	})
+
+	It("should leave the task in place when no ice-box project is known", func() {
+		IceBox = ProjectIceBox{}
+		ExecuteDecision(Decision{TaskID: "123", Action: "ice-box"})
+		Expect(mock.CalledCommands).To(BeEmpty())
+	})
+
+	It("should build strategies by name", func() {
+		s, err := NewIceBoxStrategy("label", "proj-ice", "someday")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal(LabelIceBox{Label: "someday"}))
+		s, err = NewIceBoxStrategy("project", "proj-ice", "someday")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal(ProjectIceBox{ProjectID: "proj-ice"}))
+		_, err = NewIceBoxStrategy("archive", "", "")
+		Expect(err).To(MatchError(ContainSubstring(`unknown ice-box strategy "archive"`)))
+	})
+})
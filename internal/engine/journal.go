@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	JournalPending = "pending"
+	JournalDone    = "done"
+	JournalFailed  = "failed"
+)
+
+// JournalEntry is one line of a run journal. The pending line written
+// before execution carries the whole decision; later lines only update the
+// task's status.
+type JournalEntry struct {
+	TaskID   string    `json:"task_id"`
+	Status   string    `json:"status"`
+	Decision *Decision `json:"decision,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Journal appends a line to a JSON Lines file as each decision starts out
+// pending and then executes, so a run that dies midway leaves a record of
+// which mutations were applied. --resume re-runs the rest from it.
+type Journal struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// ActiveJournal receives the outcome of every executed decision when set.
+var ActiveJournal *Journal
+
+// CreateJournal starts a journal at path, replacing any old one, with a
+// pending entry for each decision.
+func CreateJournal(path string, decisions []Decision) (*Journal, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create journal: %w", err)
+	}
+	j := &Journal{f: f, enc: json.NewEncoder(f)}
+	for _, d := range decisions {
+		if err := j.append(JournalEntry{TaskID: d.TaskID, Status: JournalPending, Decision: &d, At: NowFunc()}); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// OpenJournal reopens the journal at path to append further outcomes.
+func OpenJournal(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	// Finish a line torn by a crash, so the next entry starts afresh.
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if _, err := f.WriteString("\n"); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("open journal: %w", err)
+		}
+	}
+	return &Journal{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record notes that the decision for taskID executed, failing with err if
+// it is non-nil. A journal that can't be written is only warned about: the
+// mutation has happened either way.
+func (j *Journal) Record(taskID string, err error) {
+	entry := JournalEntry{TaskID: taskID, Status: JournalDone, At: NowFunc()}
+	if err != nil {
+		entry.Status, entry.Error = JournalFailed, err.Error()
+	}
+	if err := j.append(entry); err != nil {
+		Log.Warn("Failed to journal task %s as %s: %v", taskID, entry.Status, err)
+	}
+}
+
+// append writes entry and syncs it to disk before returning, so the line
+// survives a crash right after.
+func (j *Journal) append(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.enc.Encode(entry); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// ReadJournal folds the journal at path into one entry per decision, in the
+// order they were journaled, each with its decision and latest status.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	defer f.Close()
+	var entries []JournalEntry
+	byTask := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A crash mid-write leaves at most a torn last line.
+			Log.Warn("Ignoring unreadable journal line %d: %v", line, err)
+			continue
+		}
+		i, ok := byTask[entry.TaskID]
+		if !ok {
+			if entry.Decision == nil {
+				return nil, fmt.Errorf("journal line %d: status for unknown task %s", line, entry.TaskID)
+			}
+			byTask[entry.TaskID] = len(entries)
+			entries = append(entries, entry)
+			continue
+		}
+		entries[i].Status, entries[i].Error, entries[i].At = entry.Status, entry.Error, entry.At
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	return entries, nil
+}
+
+// UnfinishedDecisions returns the decisions of entries still pending or
+// failed.
+func UnfinishedDecisions(entries []JournalEntry) []Decision {
+	var decisions []Decision
+	for _, e := range entries {
+		if e.Status != JournalDone {
+			decisions = append(decisions, *e.Decision)
+		}
+	}
+	return decisions
+}
+
+// ResumeJournal executes the decisions the journal at path has not marked
+// done, as recorded and without asking the LLM again, and appends their
+// outcomes to it. It returns how many it executed and an error if any of
+// them failed again.
+func ResumeJournal(ctx context.Context, path string) (int, error) {
+	entries, err := ReadJournal(path)
+	if err != nil {
+		return 0, err
+	}
+	decisions := UnfinishedDecisions(entries)
+	Log.Info("Resuming %d of %d journaled decisions", len(decisions), len(entries))
+	journal, err := OpenJournal(path)
+	if err != nil {
+		return 0, err
+	}
+	defer journal.Close()
+
+	previous := ActiveJournal
+	ActiveJournal = journal
+	defer func() { ActiveJournal = previous }()
+	executed := ExecuteDecisionsParallelContext(ctx, decisions)
+
+	after, err := ReadJournal(path)
+	if err != nil {
+		return executed, err
+	}
+	if failed := len(UnfinishedDecisions(after)); failed > 0 {
+		return executed, fmt.Errorf("%d journaled decisions still not done", failed)
+	}
+	return executed, nil
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Run Journal", func() {
+	var (
+		mock *MockRunner
+		path string
+	)
+
+	reprioritize := func(taskID string, priority int) Decision {
+		return Decision{TaskID: taskID, Action: "reprioritize", Priority: &priority, CurrentPriority: 4}
+	}
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		path = filepath.Join(GinkgoT().TempDir(), "run.journal")
+		previous := ActiveJournal
+		DeferCleanup(func() { ActiveJournal = previous })
+
+		journal, err := CreateJournal(path, []Decision{reprioritize("1", 1), reprioritize("2", 2), reprioritize("3", 3)})
+		Expect(err).NotTo(HaveOccurred())
+		journal.Record("1", nil)
+		journal.Record("2", errors.New("td exited 1"))
+		Expect(journal.Close()).To(Succeed())
+	})
+
+	It("should fold each task's lines into its latest status", func() {
+		entries, err := ReadJournal(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].Status).To(Equal(JournalDone))
+		Expect(entries[1].Status).To(Equal(JournalFailed))
+		Expect(entries[1].Error).To(Equal("td exited 1"))
+		Expect(entries[2].Status).To(Equal(JournalPending))
+		Expect(*entries[2].Decision.Priority).To(Equal(3))
+	})
+
+	It("should resume only the decisions not yet done", func() {
+		executed, err := ResumeJournal(context.Background(), path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executed).To(Equal(2))
+		Expect(mock.CalledCommands).To(ConsistOf(
+			[]string{"td", "task", "update", "2", "--priority", "p2"},
+			[]string{"td", "task", "update", "3", "--priority", "p3"},
+		))
+
+		entries, err := ReadJournal(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(UnfinishedDecisions(entries)).To(BeEmpty())
+
+		mock.CalledCommands = nil
+		executed, err = ResumeJournal(context.Background(), path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executed).To(Equal(0))
+		Expect(mock.CalledCommands).To(BeEmpty())
+	})
+
+	It("should report decisions that fail again", func() {
+		mock.Errors["td"] = errors.New("td exited 1")
+		_, err := ResumeJournal(context.Background(), path)
+		Expect(err).To(MatchError("2 journaled decisions still not done"))
+	})
+
+	It("should tolerate a line torn by a crash", func() {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString(`{"task_id": "3", "sta`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		_, err = ResumeJournal(context.Background(), path)
+		Expect(err).NotTo(HaveOccurred())
+		entries, err := ReadJournal(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(UnfinishedDecisions(entries)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyStats collects the duration of each LLM call.
+type LatencyStats struct {
+	mu      sync.Mutex
+	samples []time.Duration
+
+	// Now is swappable so tests can drive a fake clock.
+	Now func() time.Time
+}
+
+func NewLatencyStats() *LatencyStats {
+	return &LatencyStats{Now: time.Now}
+}
+
+// LLMLatency times every Backend.Decide call of the run.
+var LLMLatency = NewLatencyStats()
+
+// Since records and returns the time elapsed from start, a value previously
+// taken from s.Now.
+func (s *LatencyStats) Since(start time.Time) time.Duration {
+	elapsed := s.Now().Sub(start)
+	s.Record(elapsed)
+	return elapsed
+}
+
+func (s *LatencyStats) Record(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, d)
+}
+
+// LatencySummary is the distribution of recorded latencies.
+type LatencySummary struct {
+	Count  int
+	Min    time.Duration
+	Median time.Duration
+	P95    time.Duration
+	Max    time.Duration
+}
+
+func (l LatencySummary) String() string {
+	if l.Count == 0 {
+		return "no LLM calls"
+	}
+	return fmt.Sprintf("min %s, median %s, p95 %s, max %s over %d calls", l.Min, l.Median, l.P95, l.Max, l.Count)
+}
+
+// Summary computes nearest-rank percentiles over the samples so far.
+func (s *LatencyStats) Summary() LatencySummary {
+	s.mu.Lock()
+	sorted := make([]time.Duration, len(s.samples))
+	copy(sorted, s.samples)
+	s.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return LatencySummary{}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	return LatencySummary{
+		Count:  len(sorted),
+		Min:    sorted[0],
+		Median: rank(0.5),
+		P95:    rank(0.95),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+func (l LatencySummary) seconds() map[string]float64 {
+	return map[string]float64{
+		"min":    l.Min.Seconds(),
+		"median": l.Median.Seconds(),
+		"p95":    l.P95.Seconds(),
+		"max":    l.Max.Seconds(),
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LLM Latency", func() {
+	It("should compute min, median, p95 and max from a known sample", func() {
+		stats := NewLatencyStats()
+		for i := 20; i >= 1; i-- {
+			stats.Record(time.Duration(i) * time.Second)
+		}
+
+		summary := stats.Summary()
+		Expect(summary.Count).To(Equal(20))
+		Expect(summary.Min).To(Equal(1 * time.Second))
+		Expect(summary.Median).To(Equal(10 * time.Second))
+		Expect(summary.P95).To(Equal(19 * time.Second))
+		Expect(summary.Max).To(Equal(20 * time.Second))
+	})
+
+	It("should report an empty summary before any calls", func() {
+		Expect(NewLatencyStats().Summary()).To(Equal(LatencySummary{}))
+		Expect(LatencySummary{}.String()).To(Equal("no LLM calls"))
+	})
+
+	It("should time each LLM call with the injected clock", func() {
+		clock := time.Date(2026, 2, 24, 12, 0, 0, 0, time.UTC)
+		stats := NewLatencyStats()
+		stats.Now = func() time.Time { return clock }
+		LLMLatency = stats
+		DeferCleanup(func() { LLMLatency = NewLatencyStats() })
+		Backend = backendFunc(func(prompt string) (string, error) {
+			clock = clock.Add(3 * time.Second)
+			return `{"action": "skip", "reasoning": "fine"}`, nil
+		})
+		DeferCleanup(func() { Backend = defaultBackend() })
+
+		ProcessTask(Task{ID: "1"}, &InertiaContext{})
+		ProcessTask(Task{ID: "2"}, &InertiaContext{})
+
+		summary := stats.Summary()
+		Expect(summary.Count).To(Equal(2))
+		Expect(summary.Max).To(Equal(3 * time.Second))
+	})
+})
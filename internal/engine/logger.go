@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Logger is the leveled logging surface the engine writes through. Messages
+// are printf-style.
+type Logger interface {
+	Debug(format string, args ...any)
+	Info(format string, args ...any)
+	Warn(format string, args ...any)
+	Error(format string, args ...any)
+}
+
+// slogLogger backs Logger with the standard library's log/slog handlers.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewLogger returns a Logger writing to w at or above level, formatted as
+// "text" or "json".
+func NewLogger(w io.Writer, level slog.Level, format string) (Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", "text":
+		return &slogLogger{l: slog.New(slog.NewTextHandler(w, opts))}, nil
+	case "json":
+		return &slogLogger{l: slog.New(slog.NewJSONHandler(w, opts))}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (want text or json)", format)
+}
+
+// NewLoggerFromConfig builds the logger cfg asks for: --log-level and
+// --log-format, raised to warn under --quiet.
+func NewLoggerFromConfig(w io.Writer, cfg *Config) (Logger, error) {
+	level, err := ParseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Quiet && level < slog.LevelWarn {
+		level = slog.LevelWarn
+	}
+	return NewLogger(w, level, cfg.LogFormat)
+}
+
+// ParseLogLevel accepts debug, info, warn or error.
+func ParseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+func (s *slogLogger) log(level slog.Level, format string, args []any) {
+	if !s.l.Enabled(context.Background(), level) {
+		return
+	}
+	s.l.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Debug(format string, args ...any) { s.log(slog.LevelDebug, format, args) }
+func (s *slogLogger) Info(format string, args ...any)  { s.log(slog.LevelInfo, format, args) }
+func (s *slogLogger) Warn(format string, args ...any)  { s.log(slog.LevelWarn, format, args) }
+func (s *slogLogger) Error(format string, args ...any) { s.log(slog.LevelError, format, args) }
+
+// NopLogger discards everything; useful in tests.
+type NopLogger struct{}
+
+func (NopLogger) Debug(string, ...any) {}
+func (NopLogger) Info(string, ...any)  {}
+func (NopLogger) Warn(string, ...any)  {}
+func (NopLogger) Error(string, ...any) {}
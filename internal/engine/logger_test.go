@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logger", func() {
+	It("should drop messages below the configured level", func() {
+		var buf bytes.Buffer
+		logger, err := NewLogger(&buf, slog.LevelWarn, "text")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger.Info("routine %d", 1)
+		logger.Warn("careful %d", 2)
+		Expect(buf.String()).NotTo(ContainSubstring("routine"))
+		Expect(buf.String()).To(ContainSubstring("careful 2"))
+	})
+
+	It("should emit one JSON object per line in json format", func() {
+		var buf bytes.Buffer
+		logger, err := NewLogger(&buf, slog.LevelDebug, "json")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger.Error("task %s failed", "42")
+		var entry map[string]any
+		Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+		Expect(entry["level"]).To(Equal("ERROR"))
+		Expect(entry["msg"]).To(Equal("task 42 failed"))
+	})
+
+	It("should reject unknown levels and formats", func() {
+		_, err := ParseLogLevel("loud")
+		Expect(err).To(HaveOccurred())
+		level, err := ParseLogLevel("DEBUG")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(level).To(Equal(slog.LevelDebug))
+
+		_, err = NewLogger(&bytes.Buffer{}, slog.LevelInfo, "xml")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Verbose matching output", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+		logger, err := NewLogger(buf, slog.LevelInfo, "text")
+		Expect(err).NotTo(HaveOccurred())
+		Log = logger
+		DeferCleanup(func() { Log = NopLogger{} })
+		Backend = backendFunc(func(string) (string, error) { return `{"action": "skip", "reasoning": "fine"}`, nil })
+		DeferCleanup(func() { Backend = defaultBackend() })
+	})
+
+	ctx := &InertiaContext{
+		Gazetteer: Gazetteer{Concepts: []Entity{{Name: "Journaling", Context: "10 years"}}},
+	}
+
+	It("should log matched entities per task with --verbose", func() {
+		Settings.Verbose = true
+		ProcessTask(Task{ID: "7", Content: "Journaling before bed"}, ctx)
+		Expect(buf.String()).To(ContainSubstring("Task 7 matched people: none; projects: none; concepts: Journaling"))
+	})
+
+	It("should stay quiet by default", func() {
+		ProcessTask(Task{ID: "7", Content: "Journaling before bed"}, ctx)
+		Expect(buf.String()).NotTo(ContainSubstring("matched"))
+	})
+
+	It("should log nothing below warn for a normal run in quiet mode", func() {
+		var buf bytes.Buffer
+		Settings.Quiet = true
+		Settings.Verbose = true
+		logger, err := NewLoggerFromConfig(&buf, Settings)
+		Expect(err).NotTo(HaveOccurred())
+		Log = logger
+		DeferCleanup(func() { Log = NopLogger{} })
+		CommandRunner = &MockRunner{
+			Outputs: map[string][]byte{"openclaw": []byte(`{"action": "reprioritize", "priority": 9, "reasoning": "urgent"}`)},
+			Errors:  map[string]error{},
+		}
+
+		decisions := ProcessTasksParallel([]Task{{ID: "1", Content: "Pay rent"}, {ID: "2", Content: "Call mum"}}, &InertiaContext{}, 2)
+		ExecuteDecisionsParallel(decisions)
+		Expect(buf.String()).NotTo(ContainSubstring("level=INFO"))
+		Expect(buf.String()).To(ContainSubstring("level=WARN"))
+
+		Settings.Quiet = false
+		logger, err = NewLoggerFromConfig(&buf, Settings)
+		Expect(err).NotTo(HaveOccurred())
+		Log = logger
+		ProcessTasksParallel([]Task{{ID: "1", Content: "Pay rent"}}, &InertiaContext{}, 1)
+		Expect(buf.String()).To(ContainSubstring("level=INFO"))
+	})
+})
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// llmFailures counts decisions that fell back to skip because the LLM call
+// or its response failed.
+var llmFailures atomic.Int64
+
+// RunMetrics summarizes one run for trend tracking.
+type RunMetrics struct {
+	TasksFetched        int            `json:"tasks_fetched"`
+	TasksProcessed      int            `json:"tasks_processed"`
+	Decisions           map[string]int `json:"decisions"`
+	LLMFailures         int            `json:"llm_failures"`
+	LLMLatency          LatencySummary `json:"-"`
+	AverageInertiaScore float64        `json:"average_inertia_score"`
+	DurationSeconds     float64        `json:"duration_seconds"`
+}
+
+// CollectMetrics builds the metrics for a run that fetched fetched tasks and
+// produced decisions over the given duration.
+func CollectMetrics(decisions []Decision, fetched int, duration time.Duration) RunMetrics {
+	m := RunMetrics{
+		TasksFetched:    fetched,
+		Decisions:       make(map[string]int),
+		LLMFailures:     int(llmFailures.Load()),
+		LLMLatency:      LLMLatency.Summary(),
+		DurationSeconds: duration.Seconds(),
+	}
+	var total float64
+	for _, d := range decisions {
+		if d.Action == ActionFiltered {
+			continue
+		}
+		m.TasksProcessed++
+		m.Decisions[d.Action]++
+		total += d.InertiaScore
+	}
+	if m.TasksProcessed > 0 {
+		m.AverageInertiaScore = total / float64(m.TasksProcessed)
+	}
+	return m
+}
+
+// WriteMetrics writes m to path as JSON if the path ends in .json, otherwise
+// in the Prometheus textfile exposition format. The file is replaced
+// atomically so a collector never reads a partial write.
+func WriteMetrics(m RunMetrics, path string) error {
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		doc := struct {
+			RunMetrics
+			LLMLatencySeconds map[string]float64 `json:"llm_latency_seconds"`
+		}{m, m.LLMLatency.seconds()}
+		var err error
+		if data, err = json.MarshalIndent(doc, "", "  "); err != nil {
+			return fmt.Errorf("marshal metrics: %w", err)
+		}
+	} else {
+		data = []byte(FormatPrometheus(m))
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+	return nil
+}
+
+// FormatPrometheus renders m in the Prometheus text exposition format.
+func FormatPrometheus(m RunMetrics) string {
+	var sb strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+	gauge("inertia_tasks_fetched", "Tasks fetched from the task source.", float64(m.TasksFetched))
+	gauge("inertia_tasks_processed", "Tasks that received a decision.", float64(m.TasksProcessed))
+
+	sb.WriteString("# HELP inertia_decisions Decisions made, by action.\n# TYPE inertia_decisions gauge\n")
+	actions := make([]string, 0, len(m.Decisions))
+	for action := range m.Decisions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		fmt.Fprintf(&sb, "inertia_decisions{action=%q} %d\n", action, m.Decisions[action])
+	}
+
+	gauge("inertia_llm_failures", "Tasks skipped because the LLM call or response failed.", float64(m.LLMFailures))
+	sb.WriteString("# HELP inertia_llm_latency_seconds LLM call latency (quantile 0 is min, 1 is max).\n# TYPE inertia_llm_latency_seconds gauge\n")
+	latency := m.LLMLatency.seconds()
+	for _, q := range []struct{ label, key string }{{"0", "min"}, {"0.5", "median"}, {"0.95", "p95"}, {"1", "max"}} {
+		fmt.Fprintf(&sb, "inertia_llm_latency_seconds{quantile=%q} %g\n", q.label, latency[q.key])
+	}
+	gauge("inertia_average_score", "Mean inertia score across processed tasks.", m.AverageInertiaScore)
+	gauge("inertia_run_duration_seconds", "Wall-clock duration of the run.", m.DurationSeconds)
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type backendFunc func(prompt string) (string, error)
+
+func (f backendFunc) Decide(prompt string) (string, error) { return f(prompt) }
+
+var _ = Describe("Run Metrics", func() {
+	BeforeEach(func() {
+		llmFailures.Store(0)
+		Backend = backendFunc(func(prompt string) (string, error) {
+			switch {
+			case strings.Contains(prompt, "Broken"):
+				return "", errors.New("model overloaded")
+			case strings.Contains(prompt, "Urgent"):
+				return `{"action": "reprioritize", "priority": 1, "inertia_score": 8, "reasoning": "now"}`, nil
+			}
+			return `{"action": "skip", "inertia_score": 4, "reasoning": "fine"}`, nil
+		})
+		DeferCleanup(func() { Backend = defaultBackend() })
+	})
+
+	It("should count decisions by action and LLM failures for a run", func() {
+		Settings.TrustLLMScore = true
+		tasks := []Task{
+			{ID: "1", Content: "Urgent invoice"},
+			{ID: "2", Content: "Water plants"},
+			{ID: "3", Content: "Broken task"},
+		}
+		decisions := ProcessTasksParallel(tasks, &InertiaContext{}, 2)
+		decisions = append(decisions, Decision{TaskID: "p1", Action: ActionFiltered})
+
+		m := CollectMetrics(decisions, 4, 90*time.Second)
+		Expect(m.TasksFetched).To(Equal(4))
+		Expect(m.TasksProcessed).To(Equal(3))
+		Expect(m.Decisions).To(Equal(map[string]int{"reprioritize": 1, "skip": 2}))
+		Expect(m.LLMFailures).To(Equal(1))
+		Expect(m.AverageInertiaScore).To(BeNumerically("~", 4, 0.01))
+		Expect(m.DurationSeconds).To(Equal(90.0))
+	})
+
+	It("should write the Prometheus textfile format", func() {
+		m := RunMetrics{
+			TasksFetched:        4,
+			TasksProcessed:      3,
+			Decisions:           map[string]int{"skip": 2, "reprioritize": 1},
+			LLMFailures:         1,
+			LLMLatency:          LatencySummary{Count: 3, Min: time.Second, Median: 2 * time.Second, P95: 4 * time.Second, Max: 4 * time.Second},
+			AverageInertiaScore: 4.5,
+			DurationSeconds:     90,
+		}
+		path := filepath.Join(GinkgoT().TempDir(), "inertia.prom")
+		Expect(WriteMetrics(m, path)).To(Succeed())
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		text := string(data)
+		Expect(text).To(ContainSubstring("# TYPE inertia_tasks_fetched gauge\ninertia_tasks_fetched 4\n"))
+		Expect(text).To(ContainSubstring("inertia_decisions{action=\"reprioritize\"} 1\ninertia_decisions{action=\"skip\"} 2\n"))
+		Expect(text).To(ContainSubstring("inertia_llm_failures 1\n"))
+		Expect(text).To(ContainSubstring("inertia_llm_latency_seconds{quantile=\"0.5\"} 2\n"))
+		Expect(text).To(ContainSubstring("inertia_llm_latency_seconds{quantile=\"0.95\"} 4\n"))
+		Expect(text).To(ContainSubstring("inertia_average_score 4.5\n"))
+		Expect(text).To(ContainSubstring("inertia_run_duration_seconds 90\n"))
+		for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+			if !strings.HasPrefix(line, "#") {
+				Expect(strings.Fields(line)).To(HaveLen(2), line)
+			}
+		}
+		_, err = os.Stat(path + ".tmp")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should write JSON when the path ends in .json", func() {
+		m := RunMetrics{TasksFetched: 2, Decisions: map[string]int{"skip": 2}}
+		path := filepath.Join(GinkgoT().TempDir(), "metrics.json")
+		Expect(WriteMetrics(m, path)).To(Succeed())
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		var got RunMetrics
+		Expect(json.Unmarshal(data, &got)).To(Succeed())
+		Expect(got.TasksFetched).To(Equal(2))
+		Expect(got.Decisions).To(HaveKeyWithValue("skip", 2))
+	})
+})
@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MergeTaskSources fetches from every source in turn and concatenates the
+// results, tagging each task with the Binary of the source it came from as
+// its SourceID. A task ID that two sources both use is an error, since
+// mutations could no longer be routed back.
+func MergeTaskSources(sources []TaskSource) ([]Task, error) {
+	var merged []Task
+	origin := make(map[string]string)
+	for _, source := range sources {
+		id := source.Binary()
+		tasks, err := source.FetchTasks()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		for _, task := range tasks {
+			if other, ok := origin[task.ID]; ok {
+				return nil, fmt.Errorf("task ID %s comes from both %s and %s", task.ID, other, id)
+			}
+			origin[task.ID] = id
+			task.SourceID = id
+			merged = append(merged, task)
+		}
+	}
+	return merged, nil
+}
+
+// MultiSource reads from several sources at once and sends each mutation to
+// the source its task came from. Tasks it hasn't fetched or been told about
+// by routeTask go to the first source, which also answers Binary. Project
+// IDs from ResolveProjectID are translated for each task's source.
+type MultiSource struct {
+	sources  []TaskSource
+	registry map[string]TaskSource
+
+	mu     sync.RWMutex
+	owners map[string]string
+	// projects maps a project ID ResolveProjectID returned to the ID each
+	// source knows that project by.
+	projects map[string]map[string]string
+}
+
+// NewMultiSource registers sources under their Binary, which must be
+// unique.
+func NewMultiSource(sources []TaskSource) (*MultiSource, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("no task sources")
+	}
+	m := &MultiSource{
+		sources:  sources,
+		registry: make(map[string]TaskSource),
+		owners:   make(map[string]string),
+		projects: make(map[string]map[string]string),
+	}
+	for _, source := range sources {
+		if _, ok := m.registry[source.Binary()]; ok {
+			return nil, fmt.Errorf("task source %s given twice", source.Binary())
+		}
+		m.registry[source.Binary()] = source
+	}
+	return m, nil
+}
+
+// Sources are the merged sources in the order given.
+func (m *MultiSource) Sources() []TaskSource { return m.sources }
+
+func (m *MultiSource) Binary() string { return m.sources[0].Binary() }
+
+func (m *MultiSource) FetchTasks() ([]Task, error) {
+	tasks, err := MergeTaskSources(m.sources)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, task := range tasks {
+		m.owners[task.ID] = task.SourceID
+	}
+	return tasks, nil
+}
+
+// owner is the source that task id came from.
+func (m *MultiSource) owner(id string) TaskSource {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if source, ok := m.registry[m.owners[id]]; ok {
+		return source
+	}
+	return m.sources[0]
+}
+
+// routeTask records that taskID belongs to the source sourceID when Source
+// is a MultiSource, for mutations made without fetching first. An empty
+// sourceID changes nothing.
+func routeTask(taskID, sourceID string) {
+	m, ok := Source.(*MultiSource)
+	if !ok || sourceID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.owners[taskID] = sourceID
+}
+
+// ResolveProjectID looks name up in every source and returns the first
+// source's ID for it, which the other methods translate into each task's
+// own source. It fails only if no source knows the project.
+func (m *MultiSource) ResolveProjectID(name string) (string, error) {
+	var errs []error
+	ids := make(map[string]string)
+	first := ""
+	for _, source := range m.sources {
+		id, err := source.ResolveProjectID(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Binary(), err))
+			continue
+		}
+		ids[source.Binary()] = id
+		if first == "" {
+			first = id
+		}
+	}
+	if first == "" {
+		return "", errors.Join(errs...)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.projects[first] = ids
+	return first, nil
+}
+
+// localProjectID is the ID the source sourceID knows projectID by, when
+// Source is a MultiSource that resolved it; otherwise projectID itself.
+func localProjectID(projectID, sourceID string) string {
+	if m, ok := Source.(*MultiSource); ok {
+		return m.localProjectID(projectID, sourceID)
+	}
+	return projectID
+}
+
+func (m *MultiSource) localProjectID(projectID, sourceID string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if id, ok := m.projects[projectID][sourceID]; ok {
+		return id
+	}
+	return projectID
+}
+
+func (m *MultiSource) PriorityCommand(id, priority string) []string {
+	return m.owner(id).PriorityCommand(id, priority)
+}
+
+func (m *MultiSource) ContentCommand(id, content string) []string {
+	return m.owner(id).ContentCommand(id, content)
+}
+
+func (m *MultiSource) DueCommand(id, due string) []string {
+	return m.owner(id).DueCommand(id, due)
+}
+
+func (m *MultiSource) AddSubtaskCommand(parentID, content string, labels []string, priority string) []string {
+	return m.owner(parentID).AddSubtaskCommand(parentID, content, labels, priority)
+}
+
+func (m *MultiSource) ProjectCommand(id, projectID string) []string {
+	owner := m.owner(id)
+	return owner.ProjectCommand(id, m.localProjectID(projectID, owner.Binary()))
+}
+
+func (m *MultiSource) LabelsCommand(id string, labels []string) []string {
+	return m.owner(id).LabelsCommand(id, labels)
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// stubSource serves fixed tasks and builds its commands with the embedded
+// source.
+type stubSource struct {
+	TaskSource
+	tasks []Task
+	err   error
+}
+
+func (s stubSource) FetchTasks() ([]Task, error) { return s.tasks, s.err }
+
+var _ = Describe("Merged Task Sources", func() {
+	var work, personal stubSource
+
+	BeforeEach(func() {
+		work = stubSource{TaskSource: TDSource{}, tasks: []Task{{ID: "101", Content: "Ship release"}, {ID: "102", Content: "Review PR"}}}
+		personal = stubSource{TaskSource: TaskwarriorSource{}, tasks: []Task{{ID: "a1b2", Content: "Book dentist"}}}
+		DeferCleanup(func() { Source = TDSource{} })
+	})
+
+	It("should concatenate the tasks and tag each with its source", func() {
+		tasks, err := MergeTaskSources([]TaskSource{work, personal})
+		Expect(err).NotTo(HaveOccurred())
+		var origins []string
+		for _, t := range tasks {
+			origins = append(origins, t.ID+"@"+t.SourceID)
+		}
+		Expect(origins).To(Equal([]string{"101@td", "102@td", "a1b2@task"}))
+	})
+
+	It("should reject a task ID that two sources share", func() {
+		personal.tasks = append(personal.tasks, Task{ID: "102"})
+		_, err := MergeTaskSources([]TaskSource{work, personal})
+		Expect(err).To(MatchError("task ID 102 comes from both td and task"))
+	})
+
+	It("should name the source that failed", func() {
+		personal.err = errors.New("no such file")
+		_, err := MergeTaskSources([]TaskSource{work, personal})
+		Expect(err).To(MatchError("task: no such file"))
+	})
+
+	It("should route each mutation back to the task's own source", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		multi, err := NewMultiSource([]TaskSource{work, personal})
+		Expect(err).NotTo(HaveOccurred())
+		Source = multi
+		tasks, err := FetchAllTasks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasks).To(HaveLen(3))
+
+		priority := 1
+		ExecuteDecision(Decision{TaskID: "101", Action: "reprioritize", Priority: &priority, CurrentPriority: 3})
+		ExecuteDecision(Decision{TaskID: "a1b2", Action: "reprioritize", Priority: &priority, CurrentPriority: 3})
+		Expect(mock.CalledCommands).To(Equal([][]string{
+			{"td", "task", "update", "101", "--priority", "p1"},
+			{"task", "a1b2", "modify", "priority:H"},
+		}))
+	})
+
+	Describe("without a fetch", func() {
+		var mock *MockRunner
+
+		BeforeEach(func() {
+			mock = &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+			CommandRunner = mock
+			multi, err := NewMultiSource([]TaskSource{work, personal})
+			Expect(err).NotTo(HaveOccurred())
+			Source = multi
+		})
+
+		It("should route undo entries by their recorded source", func() {
+			Expect(BuildUndoCommands([]UndoEntry{
+				{TaskID: "a1b2", SourceID: "task", Field: UndoFieldPriority, OldValue: "p3"},
+				{TaskID: "101", SourceID: "td", Field: UndoFieldPriority, OldValue: "p3"},
+			})).To(Equal([][]string{
+				{"td", "task", "update", "101", "--priority", "p3"},
+				{"task", "a1b2", "modify", "priority:L"},
+			}))
+		})
+
+		It("should route resumed decisions by their recorded source", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "run.journal")
+			priority := 1
+			journal, err := CreateJournal(path, []Decision{withFetchedValues(
+				Decision{TaskID: "a1b2", Action: "reprioritize", Priority: &priority},
+				Task{ID: "a1b2", Priority: 3, SourceID: "task"},
+			)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(journal.Close()).To(Succeed())
+
+			previous := ActiveJournal
+			DeferCleanup(func() { ActiveJournal = previous })
+			_, err = ResumeJournal(context.Background(), path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mock.CalledCommands).To(Equal([][]string{{"task", "a1b2", "modify", "priority:H"}}))
+		})
+
+		It("should move each task to its own source's ice-box project", func() {
+			mock.Outputs["td"] = []byte(`{"results": [{"id": "7", "name": "Ice Box"}]}`)
+			id, err := ResolveProjectID("Ice Box")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(id).To(Equal("7"))
+
+			IceBox = ProjectIceBox{ProjectID: id}
+			DeferCleanup(func() { IceBox = ProjectIceBox{} })
+			ExecuteDecision(Decision{TaskID: "101", SourceID: "td", Action: "ice-box"})
+			ExecuteDecision(Decision{TaskID: "a1b2", SourceID: "task", Action: "ice-box"})
+			Expect(mock.CalledCommands[1:]).To(Equal([][]string{
+				{"td", "task", "update", "101", "--project", "7"},
+				{"task", "a1b2", "modify", "project:Ice Box"},
+			}))
+
+			kept := ExcludeIceBoxed([]Task{
+				{ID: "101", SourceID: "td", ProjectID: "7"},
+				{ID: "a1b2", SourceID: "task", ProjectID: "Ice Box"},
+				{ID: "a3c4", SourceID: "task", ProjectID: "7"},
+			}, id)
+			Expect(kept).To(HaveLen(1))
+			Expect(kept[0].ID).To(Equal("a3c4"))
+		})
+	})
+
+	It("should be built from a comma-separated --source", func() {
+		source, err := NewTaskSource("td, taskwarrior")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(source).To(BeAssignableToTypeOf(&MultiSource{}))
+		_, err = NewTaskSource("td,td")
+		Expect(err).To(MatchError("task source td given twice"))
+	})
+})
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RunSummary is the payload posted to --notify-url when a run completes.
+// Text carries a one-line rendering so Slack incoming webhooks show it as
+// is; generic receivers can use the structured fields.
+type RunSummary struct {
+	Text            string         `json:"text"`
+	Date            string         `json:"date"`
+	DryRun          bool           `json:"dry_run"`
+	TasksFetched    int            `json:"tasks_fetched"`
+	TasksProcessed  int            `json:"tasks_processed"`
+	Decisions       map[string]int `json:"decisions"`
+	LLMFailures     int            `json:"llm_failures"`
+	DurationSeconds float64        `json:"duration_seconds"`
+}
+
+// NewRunSummary builds the notification for a run from its metrics.
+func NewRunSummary(date string, dryRun bool, m RunMetrics) RunSummary {
+	counts := make([]string, 0, len(m.Decisions))
+	for _, action := range actionsByCount(m.Decisions) {
+		counts = append(counts, fmt.Sprintf("%d %s", m.Decisions[action], action))
+	}
+	mode := ""
+	if dryRun {
+		mode = " (dry run)"
+	}
+	text := fmt.Sprintf("Inertia run for %s%s: %d of %d tasks processed in %s",
+		date, mode, m.TasksProcessed, m.TasksFetched, time.Duration(m.DurationSeconds*float64(time.Second)).Round(time.Second))
+	if len(counts) > 0 {
+		text += "; " + strings.Join(counts, ", ")
+	}
+	if m.LLMFailures > 0 {
+		text += fmt.Sprintf("; %d LLM failures", m.LLMFailures)
+	}
+	return RunSummary{
+		Text:            text,
+		Date:            date,
+		DryRun:          dryRun,
+		TasksFetched:    m.TasksFetched,
+		TasksProcessed:  m.TasksProcessed,
+		Decisions:       m.Decisions,
+		LLMFailures:     m.LLMFailures,
+		DurationSeconds: m.DurationSeconds,
+	}
+}
+
+// Notify posts summary as JSON to url. A nil client uses one with a 10 second
+// timeout. Any non-2xx response is an error.
+func Notify(url string, summary RunSummary, client *http.Client) error {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("marshal run summary: %w", err)
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notify: %s returned %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Run Notification", func() {
+	m := RunMetrics{
+		TasksFetched:    12,
+		TasksProcessed:  10,
+		Decisions:       map[string]int{"skip": 7, "reprioritize": 3},
+		LLMFailures:     1,
+		DurationSeconds: 95,
+	}
+
+	It("should POST the run summary as JSON", func() {
+		var contentType string
+		var payload map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			contentType = r.Header.Get("Content-Type")
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(body, &payload)).To(Succeed())
+		}))
+		defer server.Close()
+
+		Expect(Notify(server.URL, NewRunSummary("2026-02-22", false, m), server.Client())).To(Succeed())
+		Expect(contentType).To(Equal("application/json"))
+		Expect(payload).To(HaveKeyWithValue("date", "2026-02-22"))
+		Expect(payload).To(HaveKeyWithValue("dry_run", false))
+		Expect(payload).To(HaveKeyWithValue("tasks_fetched", 12.0))
+		Expect(payload).To(HaveKeyWithValue("tasks_processed", 10.0))
+		Expect(payload).To(HaveKeyWithValue("llm_failures", 1.0))
+		Expect(payload).To(HaveKeyWithValue("duration_seconds", 95.0))
+		Expect(payload).To(HaveKeyWithValue("decisions", map[string]any{"skip": 7.0, "reprioritize": 3.0}))
+		Expect(payload).To(HaveKeyWithValue("text",
+			"Inertia run for 2026-02-22: 10 of 12 tasks processed in 1m35s; 7 skip, 3 reprioritize; 1 LLM failures"))
+	})
+
+	It("should return an error for a non-2xx response", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "no such hook", http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := Notify(server.URL, NewRunSummary("2026-02-22", true, m), server.Client())
+		Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+})
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/gavmor/inertia-engine/internal/runner"
+)
+
+// PreflightCheck confirms each task source's CLI and, for a command backend,
+// the LLM CLI can be started, so a missing binary fails the run up front
+// instead of turning every task into a skip. A non-zero exit from --version
+// still counts as runnable.
+func PreflightCheck(r runner.CommandRunner) error {
+	commands := []string{Source.Binary()}
+	if m, ok := Source.(*MultiSource); ok {
+		commands = nil
+		for _, source := range m.Sources() {
+			commands = append(commands, source.Binary())
+		}
+	}
+	if b, ok := Backend.(*CommandBackend); ok {
+		commands = append(commands, b.Name)
+	}
+	for _, name := range commands {
+		_, err := r.Output(name, "--version")
+		var exitErr *exec.ExitError
+		if err == nil || errors.As(err, &exitErr) {
+			continue
+		}
+		return fmt.Errorf("preflight: cannot run %q (is it installed and on PATH? use --skip-preflight to bypass): %w", name, err)
+	}
+	return nil
+}
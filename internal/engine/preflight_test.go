@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Preflight Check", func() {
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+	})
+
+	It("should check the task CLI and the LLM command", func() {
+		Expect(PreflightCheck(mock)).To(Succeed())
+		Expect(mock.CalledCommands).To(Equal([][]string{{"td", "--version"}, {"openclaw", "--version"}}))
+	})
+
+	It("should fail with a descriptive error when a binary is missing", func() {
+		mock.Errors["openclaw"] = &exec.Error{Name: "openclaw", Err: exec.ErrNotFound}
+
+		err := PreflightCheck(mock)
+		Expect(err).To(MatchError(exec.ErrNotFound))
+		Expect(err.Error()).To(ContainSubstring(`cannot run "openclaw"`))
+		Expect(err.Error()).To(ContainSubstring("PATH"))
+	})
+
+	It("should accept a binary that exits non-zero for --version", func() {
+		mock.Errors["td"] = &exec.ExitError{}
+		Expect(PreflightCheck(mock)).To(Succeed())
+	})
+})
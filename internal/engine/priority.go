@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PriorityScale says which end of 1-4 is urgent in fetched task priorities
+// and in the priorities the LLM returns. td itself always takes p1 as the
+// most urgent.
+type PriorityScale string
+
+const (
+	// PriorityDescending: 1 is most urgent, as in td's p1-p4.
+	PriorityDescending PriorityScale = "descending"
+	// PriorityAscending: 4 is most urgent, as in the Todoist API.
+	PriorityAscending PriorityScale = "ascending"
+)
+
+func ParsePriorityScale(s string) (PriorityScale, error) {
+	switch scale := PriorityScale(s); scale {
+	case PriorityDescending, PriorityAscending:
+		return scale, nil
+	}
+	return "", fmt.Errorf("unknown priority scale %q (want descending or ascending)", s)
+}
+
+// PriorityNone is the priority of a reprioritize that clears the task's
+// priority, on either scale. It normalizes to p4, td's and Todoist's
+// default, which Taskwarrior takes as no priority at all.
+const PriorityNone = 0
+
+// NormalizePriority converts p, expressed on scale, to the td priority
+// string where p1 is most urgent.
+func NormalizePriority(p int, scale PriorityScale) string {
+	if p == PriorityNone {
+		return "p4"
+	}
+	if scale == PriorityAscending {
+		p = 5 - p
+	}
+	return fmt.Sprintf("p%d", p)
+}
+
+// describe spells out the scale for the prompt.
+func (s PriorityScale) describe() string {
+	if s == PriorityAscending {
+		return "4 = most urgent, 1 = lowest"
+	}
+	return "1 = most urgent, 4 = lowest"
+}
+
+// Priority is a priority as the LLM writes it, on Settings.PriorityScale.
+// Besides a plain number it accepts td's "p1"-"p4" labels and the names
+// urgent, high, medium and low (p1-p4 respectively), converting both to
+// the active scale, and "none" for PriorityNone.
+type Priority int
+
+var namedPriorities = map[string]int{"urgent": 1, "high": 2, "medium": 3, "low": 4}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*p = Priority(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("priority must be a number or a label, got %s", data)
+	}
+	label := strings.ToLower(strings.TrimSpace(s))
+	if label == "none" {
+		*p = PriorityNone
+		return nil
+	}
+	level, ok := namedPriorities[label]
+	if !ok {
+		switch label {
+		case "p1", "p2", "p3", "p4":
+			level = int(label[1] - '0')
+		default:
+			return fmt.Errorf("unknown priority %q", s)
+		}
+	}
+	if Settings.PriorityScale == PriorityAscending {
+		level = 5 - level
+	}
+	*p = Priority(level)
+	return nil
+}
+
+// Int returns p as a plain *int, nil when p is nil.
+func (p *Priority) Int() *int {
+	if p == nil {
+		return nil
+	}
+	n := int(*p)
+	return &n
+}
+
+// Inline priority markers, tried in order: a bracketed "(p2)" or "[p2]"
+// anywhere, a leading "p2:", or a leading "URGENT:" or "ASAP:" for p1.
+var (
+	bracketedPriority = regexp.MustCompile(`(?i)[(\[]\s*p([1-4])\s*[)\]]`)
+	leadingPriority   = regexp.MustCompile(`(?i)^\s*p([1-4])\s*[:-]\s*`)
+	urgentMarker      = regexp.MustCompile(`(?i)^\s*(?:urgent|asap)\b\s*[:!-]*\s*`)
+)
+
+// ExtractInlinePriority finds a priority the task's author wrote into its
+// content, returning it on Settings.PriorityScale together with the content
+// stripped of the marker. It returns nil and the content unchanged when
+// there is no marker.
+func ExtractInlinePriority(content string) (*int, string) {
+	level := 0
+	cleaned := content
+	if m := bracketedPriority.FindStringSubmatchIndex(content); m != nil {
+		level = int(content[m[2]] - '0')
+		cleaned = content[:m[0]] + " " + content[m[1]:]
+	} else if m := leadingPriority.FindStringSubmatch(content); m != nil {
+		level = int(m[1][0] - '0')
+		cleaned = content[len(m[0]):]
+	} else if m := urgentMarker.FindString(content); m != "" {
+		level = 1
+		cleaned = content[len(m):]
+	}
+	if level == 0 {
+		return nil, content
+	}
+	if Settings.PriorityScale == PriorityAscending {
+		level = 5 - level
+	}
+	return &level, strings.Join(strings.Fields(cleaned), " ")
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Priority Normalization", func() {
+	It("should pass priorities through on the descending scale", func() {
+		Expect(NormalizePriority(1, PriorityDescending)).To(Equal("p1"))
+		Expect(NormalizePriority(4, PriorityDescending)).To(Equal("p4"))
+	})
+
+	It("should invert priorities on the ascending scale", func() {
+		Expect(NormalizePriority(4, PriorityAscending)).To(Equal("p1"))
+		Expect(NormalizePriority(3, PriorityAscending)).To(Equal("p2"))
+		Expect(NormalizePriority(1, PriorityAscending)).To(Equal("p4"))
+	})
+
+	It("should send td the normalized priority and undo value", func() {
+		mock := &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+		CommandRunner = mock
+		UndoRecorder = &UndoLog{}
+		DeferCleanup(func() { UndoRecorder = nil })
+		Settings.PriorityScale = PriorityAscending
+
+		urgent := 4
+		ExecuteDecision(Decision{TaskID: "1", Action: "reprioritize", Priority: &urgent, CurrentPriority: 1})
+
+		Expect(mock.CalledCommands).To(Equal([][]string{{"td", "task", "update", "1", "--priority", "p1"}}))
+		Expect(UndoRecorder.Entries[0].OldValue).To(Equal("p4"))
+	})
+
+	It("should clear the priority with each source's clear command", func() {
+		mock := &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+		CommandRunner = mock
+		DeferCleanup(func() { Source = TDSource{} })
+
+		none := PriorityNone
+		ExecuteDecision(Decision{TaskID: "1", Action: "reprioritize", Priority: &none, CurrentPriority: 2})
+		Source = TaskwarriorSource{}
+		ExecuteDecision(Decision{TaskID: "a1b2", Action: "reprioritize", Priority: &none, CurrentPriority: 2})
+		ExecuteDecision(Decision{TaskID: "c3d4", Action: "reprioritize", Priority: &none, CurrentPriority: 4})
+
+		Expect(mock.CalledCommands).To(Equal([][]string{
+			{"td", "task", "update", "1", "--priority", "p4"},
+			{"task", "a1b2", "modify", "priority:"},
+		}))
+	})
+
+	It("should reject an unknown scale", func() {
+		_, err := ParsePriorityScale("sideways")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("LLM Priority Parsing", func() {
+	priorityOf := func(response string) (*int, error) {
+		decision, err := parseDecision(response, "1")
+		return decision.Priority, err
+	}
+
+	It("should accept a plain number as is", func() {
+		p, err := priorityOf(`{"action": "reprioritize", "priority": 3}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*p).To(Equal(3))
+	})
+
+	It("should accept td labels and named levels", func() {
+		for label, want := range map[string]int{`"p1"`: 1, `"P4"`: 4, `"urgent"`: 1, `"High"`: 2, `"medium"`: 3, `"low"`: 4} {
+			p, err := priorityOf(`{"action": "reprioritize", "priority": ` + label + `}`)
+			Expect(err).NotTo(HaveOccurred(), label)
+			Expect(*p).To(Equal(want), label)
+		}
+	})
+
+	It("should convert labels to the ascending scale", func() {
+		Settings.PriorityScale = PriorityAscending
+		p, err := priorityOf(`{"action": "reprioritize", "priority": "p1"}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*p).To(Equal(4))
+		p, err = priorityOf(`{"action": "reprioritize", "priority": 4}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*p).To(Equal(4))
+	})
+
+	It("should clamp an out-of-range priority into 1-4 by default", func() {
+		p, err := priorityOf(`{"action": "reprioritize", "priority": 7}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*p).To(Equal(4))
+		p, err = priorityOf(`{"action": "reprioritize", "priority": -1}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*p).To(Equal(1))
+	})
+
+	It("should read 0 and \"none\" as clearing the priority on either scale", func() {
+		for _, scale := range []PriorityScale{PriorityDescending, PriorityAscending} {
+			Settings.PriorityScale = scale
+			for _, value := range []string{`0`, `"none"`, `"None"`} {
+				p, err := priorityOf(`{"action": "reprioritize", "priority": ` + value + `}`)
+				Expect(err).NotTo(HaveOccurred(), value)
+				Expect(*p).To(Equal(PriorityNone), value)
+			}
+		}
+	})
+
+	It("should skip an out-of-range priority when clamping is off", func() {
+		Settings.ClampPriority = false
+		decision, err := parseDecision(`{"action": "reprioritize", "priority": 7}`, "1")
+		Expect(err).To(MatchError("priority 7 out of range 1-4"))
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Priority).To(BeNil())
+	})
+
+	It("should reject an unknown label", func() {
+		decision, err := parseDecision(`{"action": "reprioritize", "priority": "p7"}`, "1")
+		Expect(err).To(MatchError(ContainSubstring(`unknown priority "p7"`)))
+		Expect(decision.Action).To(Equal("skip"))
+	})
+})
+
+var _ = Describe("Inline Priority Markers", func() {
+	It("should read URGENT: as p1 and strip it", func() {
+		p, cleaned := ExtractInlinePriority("URGENT: do X")
+		Expect(*p).To(Equal(1))
+		Expect(cleaned).To(Equal("do X"))
+	})
+
+	It("should read a bracketed priority anywhere in the content", func() {
+		p, cleaned := ExtractInlinePriority("(p3) review")
+		Expect(*p).To(Equal(3))
+		Expect(cleaned).To(Equal("review"))
+		p, cleaned = ExtractInlinePriority("Review the draft [P2] today")
+		Expect(*p).To(Equal(2))
+		Expect(cleaned).To(Equal("Review the draft today"))
+	})
+
+	It("should return the priority on the configured scale", func() {
+		Settings.PriorityScale = PriorityAscending
+		p, _ := ExtractInlinePriority("p1: pay rent")
+		Expect(*p).To(Equal(4))
+	})
+
+	It("should leave content without a marker alone", func() {
+		p, cleaned := ExtractInlinePriority("Plan urgently needed trip (p5)")
+		Expect(p).To(BeNil())
+		Expect(cleaned).To(Equal("Plan urgently needed trip (p5)"))
+	})
+
+	It("should hint the marker in the prompt and, when respected, skip the LLM", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		task := Task{ID: "1", Content: "URGENT: renew passport", Priority: 3}
+		Expect(BuildDecisionPrompt(ContextualizeTask(task, &InertiaContext{}))).To(ContainSubstring("The task text itself marks it p1"))
+
+		Settings.RespectInlinePriority = true
+		decision := ProcessTask(task, &InertiaContext{})
+		Expect(decision.Action).To(Equal("reprioritize"))
+		Expect(*decision.Priority).To(Equal(1))
+		Expect(decision.Reasoning).To(Equal("task content marks it p1"))
+		Expect(mock.CalledCommands).To(BeEmpty())
+	})
+})
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return nil
+}
+
+var _ = Describe("Rate Limiting", func() {
+	var clock *fakeClock
+	var limiter *RateLimiter
+
+	BeforeEach(func() {
+		clock = &fakeClock{now: time.Date(2026, 2, 24, 12, 0, 0, 0, time.UTC)}
+		limiter = NewRateLimiter(30)
+		limiter.Now = clock.Now
+		limiter.Sleep = clock.Sleep
+	})
+
+	It("should space calls evenly at the configured rate", func() {
+		start := clock.Now()
+		var offsets []time.Duration
+		for i := 0; i < 4; i++ {
+			Expect(limiter.Wait(context.Background())).To(Succeed())
+			offsets = append(offsets, clock.Now().Sub(start))
+		}
+		Expect(offsets).To(Equal([]time.Duration{0, 2 * time.Second, 4 * time.Second, 6 * time.Second}))
+	})
+
+	It("should not accumulate a burst after an idle period", func() {
+		Expect(limiter.Wait(context.Background())).To(Succeed())
+		clock.Sleep(context.Background(), time.Minute)
+		idleEnd := clock.Now()
+
+		Expect(limiter.Wait(context.Background())).To(Succeed())
+		Expect(limiter.Wait(context.Background())).To(Succeed())
+		Expect(clock.Now().Sub(idleEnd)).To(Equal(2 * time.Second))
+	})
+
+	It("should compose with the concurrency bound in ProcessTasksParallel", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{"openclaw": []byte(`{"action": "skip"}`)}, Errors: map[string]error{}}
+		CommandRunner = mock
+		start := clock.Now()
+
+		decisions := ProcessTasksParallel([]Task{{ID: "1"}, {ID: "2"}, {ID: "3"}}, &InertiaContext{}, 1, WithRateLimiter(limiter))
+		Expect(decisions).To(HaveLen(3))
+		Expect(clock.Now().Sub(start)).To(Equal(4 * time.Second))
+	})
+
+	It("should rate-limit retries of failed calls too", func() {
+		var calls atomic.Int64
+		var callTimes []time.Duration
+		start := clock.Now()
+		Backend = backendFunc(func(string) (string, error) {
+			callTimes = append(callTimes, clock.Now().Sub(start))
+			if calls.Add(1) < 3 {
+				return "", errors.New("502 bad gateway")
+			}
+			return `{"action": "skip"}`, nil
+		})
+		DeferCleanup(func() { Backend = defaultBackend() })
+		Settings.LLMRetries = 2
+
+		decisions := ProcessTasksParallel([]Task{{ID: "1"}}, &InertiaContext{}, 1, WithRateLimiter(limiter), WithRetryBudget(NewRetryBudget(5)))
+		Expect(decisions).To(HaveLen(1))
+		Expect(decisions[0].LLMError).To(BeEmpty())
+		Expect(callTimes).To(Equal([]time.Duration{0, 2 * time.Second, 4 * time.Second}))
+	})
+
+	It("should stop waiting when the context is cancelled", func() {
+		limiter = NewRateLimiter(1)
+		Expect(limiter.Wait(context.Background())).To(Succeed())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(limiter.Wait(ctx)).To(MatchError(context.Canceled))
+	})
+})
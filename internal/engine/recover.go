@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RecoverPartialDecision makes a best effort at reading a decision from a
+// reply that was cut off mid-JSON, such as at the model's token limit. It
+// closes any unterminated string, array and object, backing off to earlier
+// commas until the text parses. Only fields that are safe on their own are
+// kept: a skip, or a reprioritize that includes its priority, which is
+// clamped or refused like a complete response's. A bare 0 counts as out of
+// range rather than clearing the priority, since only "none" says so
+// unambiguously in a reply that was cut off. Any other action becomes a
+// skip, since a truncated subtask list or rewrite can't be trusted.
+func RecoverPartialDecision(jsonish string, taskID string) (Decision, bool) {
+	start := strings.Index(jsonish, "{")
+	if start == -1 {
+		return Decision{}, false
+	}
+	type partial struct {
+		Action       string          `json:"action"`
+		Priority     json.RawMessage `json:"priority"`
+		Reasoning    string          `json:"reasoning"`
+		InertiaScore float64         `json:"inertia_score"`
+	}
+	var result partial
+	parsed := false
+	for _, candidate := range closingCandidates(jsonish[start:]) {
+		result = partial{}
+		if json.Unmarshal([]byte(candidate), &result) == nil {
+			parsed = true
+			break
+		}
+	}
+	if !parsed || result.Action == "" {
+		return Decision{}, false
+	}
+
+	decision := Decision{TaskID: taskID, Action: result.Action, Reasoning: result.Reasoning, InertiaScore: result.InertiaScore}
+	switch {
+	case result.Action == "skip":
+	case result.Action == "reprioritize" && result.Priority != nil && string(result.Priority) != "null":
+		sub, reasoning, err := resolveRecoveredPriority(result.Priority, taskID)
+		if err != nil {
+			decision.Action = "skip"
+			decision.Reasoning = reasoning
+			break
+		}
+		decision.Priority = sub.Priority
+	default:
+		decision.Action = "skip"
+		decision.Reasoning = fmt.Sprintf("truncated %s response: %s", result.Action, result.Reasoning)
+	}
+	return decision, true
+}
+
+// resolveRecoveredPriority resolves a recovered reprioritize's raw priority
+// like a complete response's, except that an explicit 0 is clamped or
+// refused as out of range instead of clearing the priority.
+func resolveRecoveredPriority(raw json.RawMessage, taskID string) (SubAction, string, error) {
+	var priority Priority
+	if err := json.Unmarshal(raw, &priority); err != nil {
+		return SubAction{}, fmt.Sprintf("Bad priority: %v", err), err
+	}
+	if priority == PriorityNone && string(raw) == "0" {
+		if !Settings.ClampPriority {
+			err := fmt.Errorf("priority %d out of range 1-4", priority)
+			return SubAction{}, err.Error(), err
+		}
+		Log.Warn("Clamped out-of-range priority 0 to 1 for task %s", taskID)
+		priority = 1
+	}
+	return rawAction{Action: "reprioritize", Priority: &priority}.resolve(taskID)
+}
+
+// closingCandidates returns s with its open strings and brackets closed,
+// followed by the same for each prefix of s ending before a comma, latest
+// first.
+func closingCandidates(s string) []string {
+	var cuts []int
+	inString, escaped := false, false
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && c == ',':
+			cuts = append(cuts, i)
+		}
+	}
+	candidates := []string{closeJSON(s)}
+	for i := len(cuts) - 1; i >= 0; i-- {
+		candidates = append(candidates, closeJSON(s[:cuts[i]]))
+	}
+	return candidates
+}
+
+// closeJSON terminates an open string in s and appends the closers for every
+// bracket still open, dropping a dangling comma or key separator first.
+func closeJSON(s string) string {
+	var stack []rune
+	inString, escaped := false, false
+	for _, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+		case (c == '}' || c == ']') && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if escaped {
+		s = s[:len(s)-1]
+	}
+	if inString {
+		s += `"`
+	}
+	s = strings.TrimRight(strings.TrimSpace(s), ",:")
+	var sb strings.Builder
+	sb.WriteString(s)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			sb.WriteByte('}')
+		} else {
+			sb.WriteByte(']')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Truncated Response Recovery", func() {
+	It("should downgrade a decompose cut off mid-subtasks to a skip without subtasks", func() {
+		resp := `{"action": "decompose", "reasoning": "too big to start", "inertia_score": 6, "subtasks": ["Outline chapters", "Draft chap`
+		decision, ok := RecoverPartialDecision(resp, "42")
+		Expect(ok).To(BeTrue())
+		Expect(decision.TaskID).To(Equal("42"))
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Subtasks).To(BeEmpty())
+		Expect(decision.Reasoning).To(Equal("truncated decompose response: too big to start"))
+		Expect(decision.InertiaScore).To(Equal(6.0))
+	})
+
+	It("should recover a decompose truncated when parsed through the normal path", func() {
+		resp := "Sure! {\"action\": \"decompose\", \"subtasks\": [\"Pick a venue\", \"Send invi"
+		decision, err := parseDecision(resp, "42")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Subtasks).To(BeNil())
+	})
+
+	It("should keep a reprioritize whose priority arrived before the cut", func() {
+		decision, ok := RecoverPartialDecision(`{"action": "reprioritize", "priority": 1, "reasoning": "due fri`, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("reprioritize"))
+		Expect(*decision.Priority).To(Equal(1))
+		Expect(decision.Reasoning).To(Equal("due fri"))
+	})
+
+	It("should clamp or refuse an out-of-range priority like a complete response", func() {
+		resp := `{"action": "reprioritize", "priority": 7, "reasoning": "urgent, very urg`
+		decision, ok := RecoverPartialDecision(resp, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("reprioritize"))
+		Expect(*decision.Priority).To(Equal(4))
+
+		Settings.ClampPriority = false
+		decision, ok = RecoverPartialDecision(resp, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Priority).To(BeNil())
+		Expect(decision.Reasoning).To(Equal("priority 7 out of range 1-4"))
+	})
+
+	It("should clamp or refuse an explicit 0 instead of clearing the priority", func() {
+		resp := `{"action": "reprioritize", "priority": 0, "reasoning": "drop ev`
+		decision, ok := RecoverPartialDecision(resp, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("reprioritize"))
+		Expect(*decision.Priority).To(Equal(1))
+
+		Settings.ClampPriority = false
+		decision, ok = RecoverPartialDecision(resp, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Reasoning).To(Equal("priority 0 out of range 1-4"))
+
+		decision, ok = RecoverPartialDecision(`{"action": "reprioritize", "priority": "none", "reasoning": "drop ev`, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("reprioritize"))
+		Expect(*decision.Priority).To(Equal(PriorityNone))
+	})
+
+	It("should skip a reprioritize cut off before its priority", func() {
+		decision, ok := RecoverPartialDecision(`{"action": "reprioritize", "reasoning": "due fri", "prio`, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Priority).To(BeNil())
+	})
+
+	It("should back off past a dangling key", func() {
+		decision, ok := RecoverPartialDecision(`{"action": "skip", "reasoning":`, "7")
+		Expect(ok).To(BeTrue())
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.Reasoning).To(BeEmpty())
+	})
+
+	It("should give up when no action survived", func() {
+		_, ok := RecoverPartialDecision(`{"reasoning": "I was about to say`, "7")
+		Expect(ok).To(BeFalse())
+		_, ok = RecoverPartialDecision(`no json at all`, "7")
+		Expect(ok).To(BeFalse())
+	})
+})
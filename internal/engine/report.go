@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ActionFiltered marks a pseudo-decision for a task that was fetched but
+// excluded before reaching the LLM.
+const ActionFiltered = "filtered"
+
+type RunReport struct {
+	Date        string     `json:"date"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	DryRun      bool       `json:"dry_run"`
+	Decisions   []Decision `json:"decisions"`
+	// Unprocessed counts tasks that never got a decision because the run
+	// was interrupted or hit its deadline.
+	Unprocessed int `json:"unprocessed,omitempty"`
+	// Previews lists the commands a dry run would have executed.
+	Previews []DryRunResult `json:"previews,omitempty"`
+	// Duplicates lists clusters of near-identical task IDs, oldest first.
+	Duplicates [][]string `json:"duplicates,omitempty"`
+	// Diagnostics shows which gazetteer entries went unused and which task
+	// words the gazetteer doesn't cover.
+	Diagnostics *MatchDiagnostics `json:"diagnostics,omitempty"`
+}
+
+// DryRunResult pairs a decision with the td commands it would run.
+type DryRunResult struct {
+	Decision Decision   `json:"decision"`
+	Commands [][]string `json:"commands"`
+}
+
+// PreviewDecisions builds a DryRunResult for every decision that would run
+// at least one command.
+func PreviewDecisions(decisions []Decision) []DryRunResult {
+	var results []DryRunResult
+	for _, d := range decisions {
+		if commands := PreviewDecision(d); len(commands) > 0 {
+			results = append(results, DryRunResult{Decision: d, Commands: commands})
+		}
+	}
+	return results
+}
+
+// FilteredDecisions returns a "filtered" pseudo-decision for every task in
+// before that did not survive into after, so each filter stage can account
+// for the tasks it dropped.
+func FilteredDecisions(before, after []Task, reason string) []Decision {
+	kept := make(map[string]bool, len(after))
+	for _, task := range after {
+		kept[task.ID] = true
+	}
+	var decisions []Decision
+	for _, task := range before {
+		if !kept[task.ID] {
+			decisions = append(decisions, Decision{
+				TaskID:    task.ID,
+				Action:    ActionFiltered,
+				Reasoning: reason,
+			})
+		}
+	}
+	return decisions
+}
+
+// RankByInertia returns decisions sorted by descending inertia score, keeping
+// the original order among equal scores.
+func RankByInertia(decisions []Decision) []Decision {
+	ranked := make([]Decision, len(decisions))
+	copy(ranked, decisions)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].InertiaScore > ranked[j].InertiaScore
+	})
+	return ranked
+}
+
+// FormatScoreTable renders decisions as an aligned table of score, task,
+// action and reasoning, in the order given.
+func FormatScoreTable(decisions []Decision) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tTASK\tACTION\tREASONING")
+	for _, d := range decisions {
+		fmt.Fprintf(w, "%.1f\t%s\t%s\t%s\n", d.InertiaScore, d.TaskID, d.Action, d.Reasoning)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// FormatActionHistogram renders how many decisions chose each action, most
+// common first, with a bar per action.
+func FormatActionHistogram(decisions []Decision) string {
+	counts := make(map[string]int)
+	for _, d := range decisions {
+		counts[d.Action]++
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, action := range actionsByCount(counts) {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", action, counts[action], strings.Repeat("#", counts[action]))
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// actionsByCount orders the actions in counts most common first, then by
+// name.
+func actionsByCount(counts map[string]int) []string {
+	actions := make([]string, 0, len(counts))
+	for action := range counts {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		if counts[actions[i]] != counts[actions[j]] {
+			return counts[actions[i]] > counts[actions[j]]
+		}
+		return actions[i] < actions[j]
+	})
+	return actions
+}
+
+// summaryTopTasks is how many of the highest-scoring tasks FormatSummary
+// lists under each action.
+const summaryTopTasks = 3
+
+// FormatSummary renders an end-of-run table grouping decisions by action,
+// most common first, with each action's count and its highest-scoring
+// tasks.
+func FormatSummary(decisions []Decision) string {
+	groups := make(map[string][]Decision)
+	counts := make(map[string]int)
+	for _, d := range RankByInertia(decisions) {
+		groups[d.Action] = append(groups[d.Action], d)
+		counts[d.Action]++
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tCOUNT\tSCORE\tTASK\tCONTENT")
+	for _, action := range actionsByCount(counts) {
+		for i, d := range groups[action][:min(summaryTopTasks, counts[action])] {
+			label, count := "", ""
+			if i == 0 {
+				label, count = action, strconv.Itoa(counts[action])
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.1f\t%s\t%s\n", label, count, d.InertiaScore, d.TaskID, d.CurrentContent)
+		}
+	}
+	fmt.Fprintf(w, "total\t%d\n", len(decisions))
+	w.Flush()
+	return sb.String()
+}
+
+// PrintDecisionsJSON writes one JSON object per decision to w, for piping
+// into tools like jq.
+func PrintDecisionsJSON(w io.Writer, decisions []Decision) error {
+	enc := json.NewEncoder(w)
+	for _, d := range decisions {
+		if err := enc.Encode(d); err != nil {
+			return fmt.Errorf("encode decision %s: %w", d.TaskID, err)
+		}
+	}
+	return nil
+}
+
+func WriteReport(path string, report RunReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decision Report", func() {
+	Context("when tasks are excluded before processing", func() {
+		It("should emit a filtered pseudo-decision with the reason for each excluded task", func() {
+			parentID := "p1"
+			tasks := []Task{
+				{ID: "p1", Content: "Parent"},
+				{ID: "c1", Content: "Child", ParentID: &parentID},
+			}
+
+			filtered := FilteredDecisions(tasks, FilterLeafNodes(tasks), "non-leaf task")
+			Expect(filtered).To(HaveLen(1))
+			Expect(filtered[0].TaskID).To(Equal("p1"))
+			Expect(filtered[0].Action).To(Equal(ActionFiltered))
+			Expect(filtered[0].Reasoning).To(Equal("non-leaf task"))
+		})
+
+		It("should account for every fetched task in the written report", func() {
+			parentID := "p1"
+			tasks := []Task{
+				{ID: "p1", Content: "Parent"},
+				{ID: "c1", Content: "Child", ParentID: &parentID},
+				{ID: "l1", Content: "Lone Task"},
+			}
+			leafTasks := FilterLeafNodes(tasks)
+			decisions := []Decision{
+				{TaskID: "c1", Action: "skip"},
+				{TaskID: "l1", Action: "skip"},
+			}
+			decisions = append(decisions, FilteredDecisions(tasks, leafTasks, "non-leaf task")...)
+
+			path := filepath.Join(GinkgoT().TempDir(), "report.json")
+			Expect(WriteReport(path, RunReport{Date: "2026-02-24", Decisions: decisions})).To(Succeed())
+
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			var report RunReport
+			Expect(json.Unmarshal(data, &report)).To(Succeed())
+
+			ids := make([]string, len(report.Decisions))
+			for i, d := range report.Decisions {
+				ids[i] = d.TaskID
+			}
+			Expect(ids).To(ConsistOf("p1", "c1", "l1"))
+		})
+	})
+
+	Context("when ranking decisions for score-only output", func() {
+		It("should sort by descending inertia score", func() {
+			decisions := []Decision{
+				{TaskID: "low", InertiaScore: 2},
+				{TaskID: "high", InertiaScore: 9},
+				{TaskID: "mid", InertiaScore: 5},
+			}
+			ranked := RankByInertia(decisions)
+			Expect(ranked[0].TaskID).To(Equal("high"))
+			Expect(ranked[1].TaskID).To(Equal("mid"))
+			Expect(ranked[2].TaskID).To(Equal("low"))
+			Expect(decisions[0].TaskID).To(Equal("low"))
+		})
+
+		It("should keep the input order for equal scores", func() {
+			decisions := []Decision{
+				{TaskID: "a", InertiaScore: 5},
+				{TaskID: "b", InertiaScore: 7},
+				{TaskID: "c", InertiaScore: 5},
+				{TaskID: "d", InertiaScore: 5},
+			}
+			ranked := RankByInertia(decisions)
+			ids := []string{ranked[0].TaskID, ranked[1].TaskID, ranked[2].TaskID, ranked[3].TaskID}
+			Expect(ids).To(Equal([]string{"b", "a", "c", "d"}))
+		})
+
+		It("should render one table row per decision", func() {
+			table := FormatScoreTable([]Decision{{TaskID: "42", Action: "skip", InertiaScore: 6.5, Reasoning: "fine"}})
+			Expect(table).To(ContainSubstring("SCORE"))
+			Expect(table).To(MatchRegexp(`6\.5\s+42\s+skip\s+fine`))
+		})
+	})
+
+	Context("when previewing a dry run", func() {
+		It("should record would-be commands without running any", func() {
+			mock := &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+			CommandRunner = mock
+			priority := 1
+			content := "Email Sam the draft"
+
+			previews := PreviewDecisions([]Decision{
+				{TaskID: "1", Action: "reprioritize", Priority: &priority, CurrentPriority: 3},
+				{TaskID: "2", Action: "recontextualize", NewContent: &content},
+				{TaskID: "3", Action: "decompose", Subtasks: []string{"Outline", "Draft"}},
+				{TaskID: "4", Action: "skip"},
+			})
+
+			Expect(previews).To(HaveLen(3))
+			Expect(previews[0].Commands).To(Equal([][]string{{"td", "task", "update", "1", "--priority", "p1"}}))
+			Expect(previews[1].Commands).To(Equal([][]string{{"td", "task", "update", "2", "--content", content}}))
+			Expect(previews[2].Decision.TaskID).To(Equal("3"))
+			Expect(previews[2].Commands).To(HaveLen(2))
+			Expect(mock.CalledCommands).To(BeEmpty())
+		})
+	})
+
+	Context("when summarizing a sample run", func() {
+		It("should count actions, most common first", func() {
+			histogram := FormatActionHistogram([]Decision{
+				{Action: "skip"}, {Action: "decompose"}, {Action: "skip"}, {Action: "skip"}, {Action: "decompose"}, {Action: "defer"},
+			})
+			lines := strings.Split(strings.TrimSpace(histogram), "\n")
+			Expect(lines).To(HaveLen(3))
+			Expect(lines[0]).To(MatchRegexp(`^skip\s+3\s+###$`))
+			Expect(lines[1]).To(MatchRegexp(`^decompose\s+2\s+##$`))
+			Expect(lines[2]).To(MatchRegexp(`^defer\s+1\s+#$`))
+		})
+	})
+
+	Context("when summarizing a run", func() {
+		It("should group decisions by action with matching counts and the top tasks by score", func() {
+			decisions := []Decision{
+				{TaskID: "1", Action: "skip", InertiaScore: 2, CurrentContent: "Water plants"},
+				{TaskID: "2", Action: "reprioritize", InertiaScore: 6, CurrentContent: "File taxes"},
+				{TaskID: "3", Action: "skip", InertiaScore: 5, CurrentContent: "Tidy desk"},
+				{TaskID: "4", Action: "skip", InertiaScore: 1, CurrentContent: "Sort socks"},
+				{TaskID: "5", Action: "skip", InertiaScore: 9, CurrentContent: "Call mum"},
+				{TaskID: "6", Action: "reprioritize", InertiaScore: 8, CurrentContent: "Book flights"},
+			}
+			lines := strings.Split(strings.TrimSpace(FormatSummary(decisions)), "\n")
+			Expect(lines).To(HaveLen(7))
+			Expect(lines[0]).To(MatchRegexp(`^ACTION\s+COUNT\s+SCORE\s+TASK\s+CONTENT$`))
+			Expect(lines[1]).To(MatchRegexp(`^skip\s+4\s+9\.0\s+5\s+Call mum$`))
+			Expect(lines[2]).To(MatchRegexp(`^\s+5\.0\s+3\s+Tidy desk$`))
+			Expect(lines[3]).To(MatchRegexp(`^\s+2\.0\s+1\s+Water plants$`))
+			Expect(lines[4]).To(MatchRegexp(`^reprioritize\s+2\s+8\.0\s+6\s+Book flights$`))
+			Expect(lines[5]).To(MatchRegexp(`^\s+6\.0\s+2\s+File taxes$`))
+			Expect(lines[6]).To(MatchRegexp(`^total\s+6$`))
+		})
+	})
+
+	Context("when printing decisions as JSON", func() {
+		It("should write one valid JSON object per line", func() {
+			priority := 1
+			var buf bytes.Buffer
+			Expect(PrintDecisionsJSON(&buf, []Decision{
+				{TaskID: "1", Action: "reprioritize", Priority: &priority, Reasoning: "urgent", InertiaScore: 8},
+				{TaskID: "2", Action: "skip", Reasoning: "fine", InertiaScore: 4},
+			})).To(Succeed())
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			for _, line := range lines {
+				var fields map[string]any
+				Expect(json.Unmarshal([]byte(line), &fields)).To(Succeed())
+				Expect(fields).To(HaveKey("task_id"))
+				Expect(fields).To(HaveKey("action"))
+				Expect(fields).To(HaveKey("reasoning"))
+				Expect(fields).To(HaveKey("inertia_score"))
+			}
+			Expect(lines[0]).To(ContainSubstring(`"priority":1`))
+		})
+	})
+})
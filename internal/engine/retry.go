@@ -0,0 +1,40 @@
+package engine
+
+import "sync/atomic"
+
+// RetryBudget caps LLM retries across a whole run, so a broadly failing
+// backend costs at most that many extra calls instead of a multiple of the
+// task count. It is safe for concurrent use; a nil budget allows no retries.
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+func NewRetryBudget(total int) *RetryBudget {
+	b := &RetryBudget{}
+	b.remaining.Store(int64(total))
+	return b
+}
+
+// Take spends one retry, reporting false once the budget is exhausted.
+func (b *RetryBudget) Take() bool {
+	if b == nil {
+		return false
+	}
+	for {
+		n := b.remaining.Load()
+		if n <= 0 {
+			return false
+		}
+		if b.remaining.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// Remaining is how many retries are left.
+func (b *RetryBudget) Remaining() int {
+	if b == nil {
+		return 0
+	}
+	return int(b.remaining.Load())
+}
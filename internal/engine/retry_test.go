@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry Budget", func() {
+	BeforeEach(func() {
+		DeferCleanup(func() { Backend = defaultBackend() })
+	})
+
+	It("should hand out exactly its total across goroutines", func() {
+		budget := NewRetryBudget(25)
+		var granted atomic.Int64
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					if budget.Take() {
+						granted.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		Expect(granted.Load()).To(Equal(int64(25)))
+		Expect(budget.Remaining()).To(BeZero())
+	})
+
+	It("should allow no retries when nil", func() {
+		var budget *RetryBudget
+		Expect(budget.Take()).To(BeFalse())
+	})
+
+	It("should cap total retries across a parallel run of a failing backend", func() {
+		var calls atomic.Int64
+		Backend = backendFunc(func(prompt string) (string, error) {
+			calls.Add(1)
+			return "", errors.New("model overloaded")
+		})
+		Settings.LLMRetries = 3
+		tasks := make([]Task, 20)
+		for i := range tasks {
+			tasks[i] = Task{ID: fmt.Sprint(i), Content: "Flaky"}
+		}
+
+		decisions := ProcessTasksParallel(tasks, &InertiaContext{}, 8, WithRetryBudget(NewRetryBudget(5)))
+		Expect(decisions).To(HaveLen(20))
+		for _, d := range decisions {
+			Expect(d.Action).To(Equal("skip"))
+		}
+		Expect(calls.Load()).To(Equal(int64(20 + 5)))
+	})
+
+	It("should recover a task whose call succeeds on retry", func() {
+		var calls atomic.Int64
+		Backend = backendFunc(func(prompt string) (string, error) {
+			if calls.Add(1) == 1 {
+				return "", errors.New("connection reset")
+			}
+			return `{"action": "reprioritize", "priority": 2, "reasoning": "due soon"}`, nil
+		})
+		budget := NewRetryBudget(3)
+		decision, err := CallAgentForDecision(TaskContext{Task: Task{ID: "1", Content: "Pay rent"}}, budget)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision.Action).To(Equal("reprioritize"))
+		Expect(budget.Remaining()).To(Equal(2))
+	})
+})
@@ -0,0 +1,350 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// neutralAlignment is the component score used when a task gives no signal
+// either way.
+const neutralAlignment = 5.0
+
+var (
+	creativeKeywords = []string{"write", "draft", "design", "draw", "compose", "create", "build", "brainstorm", "plan", "sketch", "paint", "record"}
+	adminKeywords    = []string{"email", "pay", "invoice", "file", "schedule", "book", "renew", "submit", "form", "call", "reply", "cancel", "order"}
+)
+
+// containsAny reports whether text has any of keywords as whole words, so
+// "file" doesn't match "profile". A word may carry a plural "s".
+func containsAny(text string, keywords []string) bool {
+	words := strings.Fields(NormalizeForMatch(text))
+	for _, kw := range keywords {
+		if containsPhrase(words, strings.Fields(strings.ToLower(kw))) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPhrase(words, phrase []string) bool {
+	if len(phrase) == 0 {
+		return false
+	}
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		matched := true
+		for j, p := range phrase {
+			if w := words[i+j]; w != p && w != p+"s" {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeStateAlignment scores 0-10 how well a task suits today's energy:
+// creative work wants high energy, admin is fine when energy is low.
+func ComputeStateAlignment(task Task, state State) float64 {
+	text := strings.ToLower(task.Content + " " + task.Description)
+	creative := containsAny(text, creativeKeywords)
+	admin := containsAny(text, adminKeywords)
+	energy := strings.ToLower(state.Energy)
+	switch {
+	case strings.Contains(energy, "high"):
+		if creative {
+			return 10
+		}
+		if admin {
+			return 6
+		}
+	case strings.Contains(energy, "low"):
+		if admin {
+			return 8
+		}
+		if creative {
+			return 3
+		}
+	}
+	return neutralAlignment
+}
+
+// DefaultEnvironmentKeywords maps an environment to task keywords that suit
+// it. Config entries replace the defaults for the same environment.
+func DefaultEnvironmentKeywords() map[string][]string {
+	return map[string][]string{
+		"out":         {"call", "errand", "buy", "pick up", "drop off", "shop", "post office", "groceries"},
+		"home":        {"deep work", "write", "clean", "laundry", "fix", "repair", "garden", "cook"},
+		"office":      {"deep work", "meeting", "review", "report", "email"},
+		"coffee shop": {"read", "email", "plan", "sketch"},
+	}
+}
+
+// ComputeEnvironmentAlignment scores 0-10 how well a task suits the current
+// environment: 10 if it matches a keyword for where you are, 2 if it only
+// matches keywords for somewhere else, neutral otherwise.
+func ComputeEnvironmentAlignment(task Task, env string) float64 {
+	env = strings.ToLower(env)
+	if env == "" {
+		return neutralAlignment
+	}
+	text := strings.ToLower(task.Content + " " + task.Description)
+	matchedElsewhere := false
+	for place, keywords := range Settings.EnvironmentKeywords {
+		if !containsAny(text, keywords) {
+			continue
+		}
+		if strings.Contains(env, place) {
+			return 10
+		}
+		matchedElsewhere = true
+	}
+	if matchedElsewhere {
+		return 2
+	}
+	return neutralAlignment
+}
+
+// intentionMatches reports whether text covers at least half of the content
+// words of intention.
+func intentionMatches(text []string, intention string) bool {
+	words := contentWords(intention)
+	if len(words) == 0 {
+		return false
+	}
+	found := 0
+	for _, w := range words {
+		for _, t := range text {
+			if wordsMatch(w, t) {
+				found++
+				break
+			}
+		}
+	}
+	return 2*found >= len(words)
+}
+
+// ComputeIntentionAlignment scores 0-10 how well a task serves the day's
+// intentions: 10 if it matches an explicit intention, 7 for an implicit one,
+// 3 if there are intentions but it matches none, neutral when there are
+// none at all.
+func ComputeIntentionAlignment(task Task, intentions Intentions) float64 {
+	if len(intentions.Explicit) == 0 && len(intentions.Implicit) == 0 {
+		return neutralAlignment
+	}
+	text := contentWords(task.Content + " " + task.Description)
+	for _, intention := range intentions.Explicit {
+		if intentionMatches(text, intention) {
+			return 10
+		}
+	}
+	for _, intention := range intentions.Implicit {
+		if intentionMatches(text, intention) {
+			return 7
+		}
+	}
+	return 3
+}
+
+// ScoreBreakdown shows how the computed inertia score was reached: each 0-10
+// component alongside what it contributed once weighted. Intention
+// contributes relative to neutral, so it can be negative. Total is the sum
+// of the contributions; Score is Total clamped to 0-10.
+type ScoreBreakdown struct {
+	Historical  float64 `json:"historical"`
+	State       float64 `json:"state"`
+	Environment float64 `json:"environment"`
+	Intention   float64 `json:"intention"`
+
+	HistoricalContribution  float64 `json:"historical_contribution"`
+	StateContribution       float64 `json:"state_contribution"`
+	EnvironmentContribution float64 `json:"environment_contribution"`
+	IntentionContribution   float64 `json:"intention_contribution"`
+
+	Total float64 `json:"total"`
+	Score float64 `json:"score"`
+}
+
+// BuildScoreBreakdown computes the Go-side inertia score component by
+// component: historical weight (span years, capped at 10), state alignment
+// and environment alignment, combined with the configured weights, then
+// nudged by intention alignment.
+func BuildScoreBreakdown(ctx TaskContext, weights Weights) ScoreBreakdown {
+	b := ScoreBreakdown{
+		Historical:  math.Min(ctx.HistoricalWeight, 10),
+		State:       ComputeStateAlignment(ctx.Task, ctx.State),
+		Environment: ComputeEnvironmentAlignment(ctx.Task, ctx.State.Environment),
+		Intention:   ComputeIntentionAlignment(ctx.Task, ctx.Intentions),
+	}
+	b.HistoricalContribution = b.Historical * weights.Historical
+	b.StateContribution = b.State * weights.State
+	b.EnvironmentContribution = b.Environment * weights.Environment
+	b.IntentionContribution = (b.Intention - neutralAlignment) * weights.Intention
+	b.Total = b.HistoricalContribution + b.StateContribution + b.EnvironmentContribution + b.IntentionContribution
+	b.Score = math.Max(0, math.Min(b.Total, 10))
+	return b
+}
+
+// ComputeInertiaScore is the Go-side 0-10 inertia score; see
+// BuildScoreBreakdown for how it is made up.
+func ComputeInertiaScore(ctx TaskContext, weights Weights) float64 {
+	return BuildScoreBreakdown(ctx, weights).Score
+}
+
+// Scorer settles a task's final inertia score. ctx.LLMScore is the score
+// the LLM gave, or nil when it gave none; the breakdown is always the
+// computed one, for the report.
+type Scorer interface {
+	Score(ctx TaskContext) (float64, ScoreBreakdown)
+}
+
+// LLMScorer takes the LLM's score as is, falling back to the computed score
+// only when there is none.
+type LLMScorer struct{}
+
+func (LLMScorer) Score(ctx TaskContext) (float64, ScoreBreakdown) {
+	b := BuildScoreBreakdown(ctx, Settings.Weights)
+	if ctx.LLMScore != nil {
+		return *ctx.LLMScore, b
+	}
+	return b.Score, b
+}
+
+// DeterministicScorer always uses the computed score, so the same task and
+// context score the same whatever the LLM says.
+type DeterministicScorer struct{}
+
+func (DeterministicScorer) Score(ctx TaskContext) (float64, ScoreBreakdown) {
+	b := BuildScoreBreakdown(ctx, Settings.Weights)
+	return b.Score, b
+}
+
+// HybridScorer keeps the LLM's score while it is within
+// Settings.ScoreTolerance of the computed one. Past that it warns and,
+// unless Settings.TrustLLMScore, substitutes the computed score.
+type HybridScorer struct{}
+
+func (HybridScorer) Score(ctx TaskContext) (float64, ScoreBreakdown) {
+	b := BuildScoreBreakdown(ctx, Settings.Weights)
+	if ctx.LLMScore == nil {
+		return b.Score, b
+	}
+	llm := *ctx.LLMScore
+	if math.Abs(b.Score-llm) <= Settings.ScoreTolerance {
+		return llm, b
+	}
+	if Settings.TrustLLMScore {
+		Log.Warn("Task %s: LLM inertia score %.1f diverges from computed %.1f; keeping LLM score", ctx.Task.ID, llm, b.Score)
+		return llm, b
+	}
+	Log.Warn("Task %s: LLM inertia score %.1f diverges from computed %.1f; using computed score", ctx.Task.ID, llm, b.Score)
+	return b.Score, b
+}
+
+// ActiveScorer settles every LLM decision's score; main replaces it
+// according to --scorer.
+var ActiveScorer Scorer = HybridScorer{}
+
+func NewScorer(name string) (Scorer, error) {
+	switch name {
+	case "", "hybrid":
+		return HybridScorer{}, nil
+	case "llm":
+		return LLMScorer{}, nil
+	case "deterministic":
+		return DeterministicScorer{}, nil
+	}
+	return nil, fmt.Errorf("unknown scorer %q (want llm, deterministic or hybrid)", name)
+}
+
+// ReconcileScore settles decision's score, which came from the LLM, with
+// ActiveScorer.
+func ReconcileScore(decision Decision, ctx TaskContext) Decision {
+	llm := decision.InertiaScore
+	ctx.LLMScore = &llm
+	decision.InertiaScore, _ = ActiveScorer.Score(ctx)
+	return decision
+}
+
+// DecayedWeight halves base for every halfLifeDays of task age, so a long
+// concept history pulls less on a task that has sat untouched for years. A
+// non-positive half-life disables decay.
+func DecayedWeight(base float64, ageDays int, halfLifeDays int) float64 {
+	if halfLifeDays <= 0 || ageDays <= 0 {
+		return base
+	}
+	return base * math.Pow(0.5, float64(ageDays)/float64(halfLifeDays))
+}
+
+// LabelWeightBonus sums the bonuses of the labels a task carries, matched
+// case-insensitively, so a label like "health" can mark a task as long-term
+// important without any diary history behind it.
+func LabelWeightBonus(labels []string, bonuses map[string]float64) float64 {
+	if len(bonuses) == 0 {
+		return 0
+	}
+	byLabel := make(map[string]float64, len(bonuses))
+	for label, bonus := range bonuses {
+		byLabel[strings.ToLower(label)] += bonus
+	}
+	var total float64
+	for _, label := range labels {
+		total += byLabel[strings.ToLower(label)]
+	}
+	return total
+}
+
+// WeightAggregator folds the span years of every concept a task matches into
+// its historical weight.
+type WeightAggregator interface {
+	Aggregate(spans []float64) float64
+}
+
+// MaxAggregator uses the longest span alone.
+type MaxAggregator struct{}
+
+func (MaxAggregator) Aggregate(spans []float64) float64 {
+	var longest float64
+	for _, span := range spans {
+		longest = math.Max(longest, span)
+	}
+	return longest
+}
+
+// BoostedAggregator starts from the longest span and adds each further span,
+// longest first, scaled by Decay raised to its rank, so a task rooted in
+// several long-lived concepts outweighs one rooted in a single concept.
+// The result is capped at 10.
+type BoostedAggregator struct {
+	Decay float64
+}
+
+func (b BoostedAggregator) Aggregate(spans []float64) float64 {
+	sorted := append([]float64(nil), spans...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+	var total float64
+	factor := 1.0
+	for _, span := range sorted {
+		total += span * factor
+		factor *= b.Decay
+	}
+	return math.Min(total, 10)
+}
+
+// Aggregator computes historical weight in ContextualizeTask; main replaces
+// it according to --weight-aggregator.
+var Aggregator WeightAggregator = MaxAggregator{}
+
+func NewWeightAggregator(name string) (WeightAggregator, error) {
+	switch name {
+	case "", "max":
+		return MaxAggregator{}, nil
+	case "boosted":
+		return BoostedAggregator{Decay: 0.5}, nil
+	}
+	return nil, fmt.Errorf("unknown weight aggregator %q (want max or boosted)", name)
+}
@@ -0,0 +1,320 @@
+package engine
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Computed Inertia Score", func() {
+	weights := Weights{Historical: 0.4, State: 0.3, Environment: 0.3}
+
+	It("should combine the components with the configured weights", func() {
+		ctx := TaskContext{
+			Task:             Task{Content: "Write the chapter draft"},
+			State:            State{Energy: "high"},
+			HistoricalWeight: 10,
+		}
+		// 10*0.4 + 10*0.3 + 5*0.3
+		Expect(ComputeInertiaScore(ctx, weights)).To(BeNumerically("~", 8.5))
+	})
+
+	It("should cap historical weight at 10 years", func() {
+		ctx := TaskContext{Task: Task{Content: "Something"}, HistoricalWeight: 25}
+		// 10*0.4 + 5*0.3 + 5*0.3
+		Expect(ComputeInertiaScore(ctx, weights)).To(BeNumerically("~", 7))
+	})
+
+	It("should follow custom weights", func() {
+		ctx := TaskContext{Task: Task{Content: "Pay invoice"}, State: State{Energy: "low"}, HistoricalWeight: 2}
+		// 2*0.2 + 8*0.6 + 5*0.2
+		Expect(ComputeInertiaScore(ctx, Weights{Historical: 0.2, State: 0.6, Environment: 0.2})).To(BeNumerically("~", 6.2))
+	})
+
+	It("should break the score down into contributions that sum to the total", func() {
+		ctx := TaskContext{
+			Task:             Task{Content: "Write the chapter draft"},
+			State:            State{Energy: "high", Environment: "home"},
+			HistoricalWeight: 4,
+			Intentions:       Intentions{Explicit: []string{"finish the book"}},
+		}
+		w := Weights{Historical: 0.4, State: 0.3, Environment: 0.3, Intention: 0.2}
+		b := BuildScoreBreakdown(ctx, w)
+		Expect(b.Historical).To(Equal(4.0))
+		Expect(b.State).To(Equal(10.0))
+		Expect(b.Environment).To(Equal(10.0))
+		Expect(b.Intention).To(Equal(3.0))
+		Expect(b.HistoricalContribution).To(BeNumerically("~", 1.6))
+		Expect(b.IntentionContribution).To(BeNumerically("~", -0.4))
+		sum := b.HistoricalContribution + b.StateContribution + b.EnvironmentContribution + b.IntentionContribution
+		Expect(b.Total).To(BeNumerically("~", sum))
+		Expect(b.Total).To(BeNumerically("~", 7.2))
+		Expect(b.Score).To(Equal(ComputeInertiaScore(ctx, w)))
+	})
+
+	It("should attach the breakdown to each decision and its report entry", func() {
+		DeferCleanup(func() { Backend = defaultBackend() })
+		Backend = backendFunc(func(string) (string, error) {
+			return `{"action": "skip", "reasoning": "fine", "inertia_score": 9}`, nil
+		})
+		decision := ProcessTask(Task{ID: "1", Content: "Pay invoice"}, &InertiaContext{State: State{Energy: "low"}})
+		Expect(decision.ScoreBreakdown).NotTo(BeNil())
+		Expect(decision.ScoreBreakdown.State).To(Equal(8.0))
+
+		data, err := json.Marshal(RunReport{Decisions: []Decision{decision}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"score_breakdown":{"historical":0,"state":8,`))
+	})
+
+	DescribeTable("state alignment",
+		func(content, energy string, expected float64) {
+			Expect(ComputeStateAlignment(Task{Content: content}, State{Energy: energy})).To(Equal(expected))
+		},
+		Entry("high energy, creative task", "Write essay", "high", 10.0),
+		Entry("low energy, admin task", "Pay rent", "low", 8.0),
+		Entry("low energy, admin keyword inside a word", "Update profile", "low", 5.0),
+		Entry("low energy, plural admin keyword", "File receipts", "low", 8.0),
+		Entry("low energy, creative task", "Design logo", "low", 3.0),
+		Entry("no signal", "Misc", "medium", 5.0),
+	)
+
+	DescribeTable("environment alignment",
+		func(content, env string, expected float64) {
+			Expect(ComputeEnvironmentAlignment(Task{Content: content}, env)).To(Equal(expected))
+		},
+		Entry("phone call while out", "Call the dentist", "out and about", 10.0),
+		Entry("errand while out", "Errand: drop off parcel", "out", 10.0),
+		Entry("deep work at home", "Deep work on thesis", "home", 10.0),
+		Entry("deep work at the office", "Deep work on thesis", "office", 10.0),
+		Entry("errand while at home", "Buy stamps", "home", 2.0),
+		Entry("no keyword match", "Think about stuff", "home", 5.0),
+		Entry("unknown environment", "Call the dentist", "", 5.0),
+	)
+
+	It("should use environment keywords from config", func() {
+		Settings.EnvironmentKeywords = map[string][]string{"train": {"podcast"}}
+		Expect(ComputeEnvironmentAlignment(Task{Content: "Edit podcast"}, "on the train")).To(Equal(10.0))
+		Expect(ComputeEnvironmentAlignment(Task{Content: "Call the dentist"}, "out")).To(Equal(5.0))
+	})
+
+	It("should surface environment alignment in the prompt", func() {
+		taskCtx := ContextualizeTask(Task{Content: "Call the dentist"}, &InertiaContext{State: State{Environment: "out"}})
+		Expect(taskCtx.EnvironmentAlignment).To(Equal(10.0))
+		Expect(BuildDecisionPrompt(taskCtx)).To(ContainSubstring("Environment alignment for this task: 10/10"))
+	})
+
+	Describe("ReconcileScore", func() {
+		ctx := TaskContext{Task: Task{ID: "1", Content: "Misc"}}
+
+		It("should keep the LLM score within tolerance", func() {
+			decision := ReconcileScore(Decision{TaskID: "1", InertiaScore: 4}, ctx)
+			Expect(decision.InertiaScore).To(Equal(4.0))
+		})
+
+		It("should prefer the computed score when they diverge", func() {
+			decision := ReconcileScore(Decision{TaskID: "1", InertiaScore: 10}, ctx)
+			Expect(decision.InertiaScore).To(BeNumerically("~", 3.0))
+		})
+
+		It("should keep the LLM score when told to trust it", func() {
+			Settings.TrustLLMScore = true
+			decision := ReconcileScore(Decision{TaskID: "1", InertiaScore: 10}, ctx)
+			Expect(decision.InertiaScore).To(Equal(10.0))
+		})
+	})
+})
+
+var _ = Describe("Scorers", func() {
+	ctx := TaskContext{
+		Task:             Task{ID: "1", Content: "Write the chapter draft"},
+		State:            State{Energy: "high"},
+		HistoricalWeight: 10,
+	}
+	llm := func(score float64) TaskContext {
+		c := ctx
+		c.LLMScore = &score
+		return c
+	}
+
+	It("should score known inputs deterministically whatever the LLM said", func() {
+		// 10*0.4 + 10*0.3 + 5*0.3
+		score, b := DeterministicScorer{}.Score(llm(1))
+		Expect(score).To(BeNumerically("~", 8.5))
+		Expect(b.Score).To(Equal(score))
+
+		other, _ := DeterministicScorer{}.Score(llm(9))
+		Expect(other).To(Equal(score))
+
+		unscored, _ := DeterministicScorer{}.Score(TaskContext{Task: Task{Content: "Misc"}})
+		// 0*0.4 + 5*0.3 + 5*0.3
+		Expect(unscored).To(BeNumerically("~", 3))
+	})
+
+	It("should take the LLM's score with the llm scorer", func() {
+		score, b := LLMScorer{}.Score(llm(1))
+		Expect(score).To(Equal(1.0))
+		Expect(b.Score).To(BeNumerically("~", 8.5))
+		score, _ = LLMScorer{}.Score(ctx)
+		Expect(score).To(BeNumerically("~", 8.5))
+	})
+
+	It("should reconcile the two with the hybrid scorer", func() {
+		score, _ := HybridScorer{}.Score(llm(7))
+		Expect(score).To(Equal(7.0))
+		score, _ = HybridScorer{}.Score(llm(1))
+		Expect(score).To(BeNumerically("~", 8.5))
+	})
+
+	It("should settle decisions through the active scorer", func() {
+		Backend = backendFunc(func(string) (string, error) {
+			return `{"action": "skip", "reasoning": "fine", "inertia_score": 1}`, nil
+		})
+		DeferCleanup(func() {
+			Backend = defaultBackend()
+			ActiveScorer = HybridScorer{}
+		})
+		task := Task{ID: "1", Content: "Misc"}
+		ActiveScorer = LLMScorer{}
+		Expect(ProcessTask(task, &InertiaContext{}).InertiaScore).To(Equal(1.0))
+		ActiveScorer = DeterministicScorer{}
+		Expect(ProcessTask(task, &InertiaContext{}).InertiaScore).To(BeNumerically("~", 3))
+	})
+
+	It("should be selected by name", func() {
+		for name, want := range map[string]Scorer{"": HybridScorer{}, "hybrid": HybridScorer{}, "llm": LLMScorer{}, "deterministic": DeterministicScorer{}} {
+			scorer, err := NewScorer(name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(scorer).To(Equal(want))
+		}
+		_, err := NewScorer("vibes")
+		Expect(err).To(MatchError(ContainSubstring(`unknown scorer "vibes"`)))
+	})
+})
+
+var _ = Describe("Historical Weight Aggregation", func() {
+	ctx := &InertiaContext{
+		Gazetteer: Gazetteer{
+			Concepts: []Entity{
+				{Name: "Writing", SpanYears: json.RawMessage("6")},
+				{Name: "Cooking", SpanYears: json.RawMessage("4")},
+				{Name: "Gardening", SpanYears: json.RawMessage("2")},
+			},
+		},
+	}
+	single := Task{Content: "Writing session"}
+	triple := Task{Content: "Writing about cooking and gardening"}
+
+	It("should take the longest span by default", func() {
+		Expect(ContextualizeTask(single, ctx).HistoricalWeight).To(Equal(6.0))
+		Expect(ContextualizeTask(triple, ctx).HistoricalWeight).To(Equal(6.0))
+	})
+
+	It("should boost tasks matching several concepts when configured", func() {
+		Aggregator = BoostedAggregator{Decay: 0.5}
+		DeferCleanup(func() { Aggregator = MaxAggregator{} })
+
+		Expect(ContextualizeTask(single, ctx).HistoricalWeight).To(Equal(6.0))
+		// 6 + 4*0.5 + 2*0.25
+		Expect(ContextualizeTask(triple, ctx).HistoricalWeight).To(Equal(8.5))
+	})
+
+	It("should cap the boosted weight at 10", func() {
+		Expect(BoostedAggregator{Decay: 0.5}.Aggregate([]float64{9, 8, 7})).To(Equal(10.0))
+	})
+
+	It("should reject an unknown aggregator name", func() {
+		_, err := NewWeightAggregator("sum")
+		Expect(err).To(MatchError(ContainSubstring("unknown weight aggregator")))
+	})
+})
+
+var _ = Describe("Historical Weight Decay", func() {
+	It("should leave a brand-new task's weight untouched", func() {
+		Expect(DecayedWeight(8, 0, 180)).To(Equal(8.0))
+	})
+
+	It("should halve the weight at one half-life", func() {
+		Expect(DecayedWeight(8, 180, 180)).To(BeNumerically("~", 4, 1e-9))
+	})
+
+	It("should quarter the weight at two half-lives", func() {
+		Expect(DecayedWeight(8, 360, 180)).To(BeNumerically("~", 2, 1e-9))
+	})
+
+	It("should not decay when the half-life is disabled", func() {
+		Expect(DecayedWeight(8, 400, 0)).To(Equal(8.0))
+	})
+
+	It("should show the decayed weight in the prompt", func() {
+		NowFunc = func() time.Time { return time.Date(2026, 2, 24, 0, 0, 0, 0, time.UTC) }
+		DeferCleanup(func() { NowFunc = time.Now })
+		ctx := &InertiaContext{
+			Gazetteer: Gazetteer{Concepts: []Entity{{Name: "Writing", SpanYears: json.RawMessage("8")}}},
+		}
+		task := Task{Content: "Writing retreat", AddedAt: time.Date(2025, 8, 28, 0, 0, 0, 0, time.UTC)}
+
+		taskCtx := ContextualizeTask(task, ctx)
+		Expect(taskCtx.HistoricalWeight).To(BeNumerically("~", 4, 1e-9))
+		Expect(BuildDecisionPrompt(taskCtx)).To(ContainSubstring("Historical weight after age decay: 4.0 (half-life 180 days)"))
+	})
+})
+
+var _ = Describe("Label Weight Bonuses", func() {
+	It("should give a health-labelled task with no concept match a weight of 3", func() {
+		Settings.LabelWeightBonuses = map[string]float64{"health": 3}
+		taskCtx := ContextualizeTask(Task{Content: "Book a physio appointment", Labels: []string{"Health"}}, &InertiaContext{})
+		Expect(taskCtx.RelatedConcepts).To(BeEmpty())
+		Expect(taskCtx.HistoricalWeight).To(Equal(3.0))
+	})
+
+	It("should sum the bonuses of every matching label", func() {
+		bonuses := map[string]float64{"health": 3, "career": 2}
+		Expect(LabelWeightBonus([]string{"career", "health", "errand"}, bonuses)).To(Equal(5.0))
+		Expect(LabelWeightBonus([]string{"errand"}, bonuses)).To(Equal(0.0))
+		Expect(LabelWeightBonus([]string{"health"}, nil)).To(Equal(0.0))
+	})
+})
+
+var _ = Describe("Intention Alignment", func() {
+	intentions := Intentions{
+		Explicit: []string{"Finish the grant proposal"},
+		Implicit: []string{"Get outdoors more"},
+	}
+
+	DescribeTable("alignment",
+		func(content string, intentions Intentions, expected float64) {
+			Expect(ComputeIntentionAlignment(Task{Content: content}, intentions)).To(Equal(expected))
+		},
+		Entry("matches an explicit intention", "Draft grant proposal budget", intentions, 10.0),
+		Entry("matches an implicit intention", "Get outdoors: hike with Sam", intentions, 7.0),
+		Entry("matches no intention", "Reorganize the garage", intentions, 3.0),
+		Entry("no intentions stated", "Reorganize the garage", Intentions{}, 5.0),
+	)
+
+	It("should score a task matching an explicit intention higher", func() {
+		weights := Settings.Weights
+		aligned := TaskContext{Task: Task{Content: "Draft grant proposal budget"}, Intentions: intentions}
+		unaligned := TaskContext{Task: Task{Content: "Reorganize the garage"}, Intentions: intentions}
+		Expect(ComputeInertiaScore(aligned, weights)).To(BeNumerically(">", ComputeInertiaScore(unaligned, weights)))
+		Expect(ComputeInertiaScore(TaskContext{Task: aligned.Task}, weights)).To(BeNumerically("<", ComputeInertiaScore(aligned, weights)))
+	})
+
+	It("should list the day's intentions in the prompt", func() {
+		taskCtx := ContextualizeTask(Task{Content: "Draft grant proposal budget"}, &InertiaContext{Intentions: intentions})
+		prompt := BuildDecisionPrompt(taskCtx)
+		Expect(prompt).To(ContainSubstring("Today's intentions:\n- Finish the grant proposal (explicit)\n- Get outdoors more (implicit)\n"))
+		Expect(prompt).To(ContainSubstring("Intention alignment for this task: 10/10"))
+		Expect(prompt).To(ContainSubstring("serves an explicit intention"))
+	})
+
+	It("should lean old misaligned tasks toward ice-box", func() {
+		taskCtx := TaskContext{Task: Task{Content: "Reorganize the garage"}, Intentions: intentions, AgeDays: 90, IntentionAlignment: 3}
+		Expect(BuildDecisionPrompt(taskCtx)).To(ContainSubstring("lean toward \"ice-box\""))
+	})
+
+	It("should leave intentions out of the prompt when there are none", func() {
+		Expect(BuildDecisionPrompt(TaskContext{Task: Task{Content: "Anything"}})).NotTo(ContainSubstring("intentions"))
+	})
+})
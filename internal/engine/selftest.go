@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gavmor/inertia-engine/internal/runner"
+)
+
+// selfTestPrompt asks for the smallest valid decision.
+const selfTestPrompt = `This is a connectivity check. Reply with exactly this JSON and nothing else: {"action": "skip", "reasoning": "self-test"}`
+
+// SelfTestCheck is one line of the selftest checklist; Err is nil when it
+// passed.
+type SelfTestCheck struct {
+	Name string
+	Err  error
+}
+
+type SelfTestResult struct {
+	Checks []SelfTestCheck
+}
+
+// Passed reports whether every check passed.
+func (r SelfTestResult) Passed() bool {
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the checklist, one check per line.
+func (r SelfTestResult) String() string {
+	var sb strings.Builder
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			sb.WriteString(fmt.Sprintf("[FAIL] %s: %v\n", c.Name, c.Err))
+		} else {
+			sb.WriteString(fmt.Sprintf("[PASS] %s\n", c.Name))
+		}
+	}
+	return sb.String()
+}
+
+// RunSelfTest checks the whole toolchain without touching any task: the
+// context file loads, the task source CLI responds, the LLM answers a
+// trivial prompt with a parseable decision, and the report and metrics
+// paths are writable. Every check runs even after one fails.
+func RunSelfTest(r runner.CommandRunner, cfg Config) SelfTestResult {
+	var result SelfTestResult
+	check := func(name string, err error) {
+		result.Checks = append(result.Checks, SelfTestCheck{Name: name, Err: err})
+	}
+
+	if cfg.Context == "" {
+		check("context file loads", errors.New("--context is required"))
+	} else {
+		_, err := LoadContext(cfg.Context)
+		check("context file loads", err)
+	}
+
+	_, err := r.Output(Source.Binary(), "--version")
+	check(fmt.Sprintf("%s responds", Source.Binary()), err)
+
+	check("LLM returns a parseable decision", selfTestLLM(r))
+
+	for _, p := range []struct{ flag, path string }{{"report", cfg.Report}, {"metrics", cfg.Metrics}} {
+		if p.path != "" {
+			check(fmt.Sprintf("--%s path %s is writable", p.flag, p.path), checkWritable(p.path))
+		}
+	}
+	return result
+}
+
+// selfTestLLM sends selfTestPrompt through the configured backend, running
+// a command backend through r.
+func selfTestLLM(r runner.CommandRunner) error {
+	var output string
+	var err error
+	if b, ok := Backend.(*CommandBackend); ok {
+		var out []byte
+		out, err = r.RunWithStdin(selfTestPrompt, b.Name, b.Args...)
+		output = string(out)
+	} else if Backend != nil {
+		output, err = Backend.Decide(selfTestPrompt)
+	} else {
+		err = errors.New("no LLM backend configured")
+	}
+	if err != nil {
+		return err
+	}
+	decision, err := parseDecision(output, "selftest")
+	if err != nil {
+		return fmt.Errorf("unparseable response %q: %w", output, err)
+	}
+	if decision.Action != "skip" {
+		return fmt.Errorf("expected a skip decision, got %q", decision.Action)
+	}
+	return nil
+}
+
+// checkWritable confirms a file can be created next to path without
+// touching path itself.
+func checkWritable(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".inertia-selftest-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Self-test", func() {
+	var mock *MockRunner
+	var cfg Config
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+		dir := GinkgoT().TempDir()
+		contextPath := filepath.Join(dir, "context.json")
+		Expect(os.WriteFile(contextPath, []byte(`{"date": "2026-02-22"}`), 0644)).To(Succeed())
+		cfg = *DefaultConfig()
+		cfg.Context = contextPath
+		cfg.Report = filepath.Join(dir, "report.json")
+		cfg.Metrics = filepath.Join(dir, "metrics.json")
+	})
+
+	It("should pass every check against a healthy toolchain", func() {
+		mock.Outputs["openclaw"] = []byte(`{"action": "skip", "reasoning": "self-test"}`)
+
+		result := RunSelfTest(mock, cfg)
+		Expect(result.Passed()).To(BeTrue(), result.String())
+		Expect(result.Checks).To(HaveLen(5))
+		Expect(result.String()).To(HavePrefix("[PASS] context file loads\n[PASS] td responds\n[PASS] LLM returns a parseable decision\n"))
+		Expect(mock.StdinSent).To(Equal(selfTestPrompt))
+		Expect(cfg.Report).NotTo(BeAnExistingFile())
+	})
+
+	It("should fail the LLM check on an unparseable reply and keep checking", func() {
+		mock.Outputs["openclaw"] = []byte("Sure! Happy to help.")
+
+		result := RunSelfTest(mock, cfg)
+		Expect(result.Passed()).To(BeFalse())
+		Expect(result.Checks[2].Err).To(MatchError(ContainSubstring("unparseable response")))
+		Expect(result.String()).To(ContainSubstring("[FAIL] LLM returns a parseable decision"))
+		Expect(result.Checks).To(HaveLen(5))
+	})
+
+	It("should report a missing LLM command and an unwritable path", func() {
+		mock.Errors["openclaw"] = errors.New("executable file not found")
+		cfg.Report = filepath.Join(cfg.Report, "missing-dir", "report.json")
+
+		result := RunSelfTest(mock, cfg)
+		Expect(result.Passed()).To(BeFalse())
+		Expect(result.Checks[2].Err).To(HaveOccurred())
+		Expect(result.Checks[3].Err).To(HaveOccurred())
+		Expect(result.Checks[4].Err).NotTo(HaveOccurred())
+	})
+})
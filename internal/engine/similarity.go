@@ -0,0 +1,242 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var stopwords = map[string]bool{"a": true, "an": true, "the": true, "to": true, "of": true, "and": true, "for": true}
+
+// normalizeText lowercases s, trims it and collapses inner whitespace.
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// NormalizeForMatch prepares text for gazetteer matching: it splits
+// camelCase words ("FollowUpWithDana" becomes "follow up with dana"),
+// lowercases, turns punctuation and emoji into spaces and collapses
+// whitespace.
+func NormalizeForMatch(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune(' ')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// TextSimilarity scores 0-1 how alike two short phrases are, comparing their
+// words with stopwords removed. Words match when equal or when one is a
+// prefix of the other ("intro"/"introduction").
+func TextSimilarity(a, b string) float64 {
+	ta, tb := contentWords(a), contentWords(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	used := make([]bool, len(tb))
+	matches := 0
+	for _, wa := range ta {
+		for j, wb := range tb {
+			if !used[j] && wordsMatch(wa, wb) {
+				used[j] = true
+				matches++
+				break
+			}
+		}
+	}
+	return 2 * float64(matches) / float64(len(ta)+len(tb))
+}
+
+func contentWords(s string) []string {
+	var words []string
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		w = strings.Trim(w, ".,;:!?\"'()")
+		if w != "" && !stopwords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func wordsMatch(a, b string) bool {
+	if a == b {
+		return true
+	}
+	short, long := a, b
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	return len(short) >= 3 && strings.HasPrefix(long, short)
+}
+
+// DedupeSubtasks drops subtasks that repeat an earlier one, either exactly
+// after normalizing case and whitespace or, when Settings.SubtaskSimilarity
+// is above zero, by TextSimilarity at or above that threshold. The first
+// occurrence is kept as written.
+func DedupeSubtasks(subs []string) []string {
+	var kept []string
+	seen := make(map[string]bool)
+	for _, sub := range subs {
+		norm := normalizeText(sub)
+		if norm == "" || seen[norm] {
+			continue
+		}
+		duplicate := false
+		if Settings.SubtaskSimilarity > 0 {
+			for _, k := range kept {
+				if TextSimilarity(k, sub) >= Settings.SubtaskSimilarity {
+					duplicate = true
+					break
+				}
+			}
+		}
+		if duplicate {
+			continue
+		}
+		seen[norm] = true
+		kept = append(kept, sub)
+	}
+	return kept
+}
+
+// restatesTask reports whether subtask merely repeats the parent task's
+// content: every content word of each matches one of the other's, so only
+// stopwords, punctuation, order or abbreviation differ. This is much
+// stricter than Settings.SubtaskSimilarity, since a subtask naturally
+// extends its parent's wording ("Write report" → "Write report
+// introduction").
+func restatesTask(subtask, parent string) bool {
+	return TextSimilarity(subtask, parent) == 1
+}
+
+// DropDegenerateSubtasks removes the subtasks of a decompose that only
+// restate parent, the task's own content, since adding them creates a
+// useless child. A decompose left without subtasks becomes a skip; in a
+// combined decision only the decompose is dropped.
+func DropDegenerateSubtasks(d Decision, parent string) Decision {
+	keep := func(subtasks []string) []string {
+		var kept []string
+		for _, subtask := range subtasks {
+			if restatesTask(subtask, parent) {
+				Log.Info("Dropping subtask %q of task %s: it restates the task", subtask, d.TaskID)
+				continue
+			}
+			kept = append(kept, subtask)
+		}
+		return kept
+	}
+	if len(d.Actions) > 0 {
+		var subs []SubAction
+		for _, sub := range d.Actions {
+			if sub.Action == "decompose" {
+				if sub.Subtasks = keep(sub.Subtasks); len(sub.Subtasks) == 0 {
+					continue
+				}
+			}
+			subs = append(subs, sub)
+		}
+		return withSubActions(d, subs)
+	}
+	if d.Action != "decompose" || len(d.Subtasks) == 0 {
+		return d
+	}
+	if d.Subtasks = keep(d.Subtasks); len(d.Subtasks) == 0 {
+		return downgradeToSkip(d, "every subtask restates the task")
+	}
+	return d
+}
+
+// ActionMerge marks a duplicate task folded into an older one; it executes
+// like ice-box.
+const ActionMerge = "merge"
+
+// FindDuplicateTasks groups tasks whose content is alike by TextSimilarity at
+// or above threshold, linking transitively. Each returned cluster holds at
+// least two task IDs, oldest first; clusters keep the order of their first
+// task in the input.
+func FindDuplicateTasks(tasks []Task, threshold float64) [][]string {
+	parent := make([]int, len(tasks))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range tasks {
+		for j := i + 1; j < len(tasks); j++ {
+			a, b := tasks[i].Content, tasks[j].Content
+			if normalizeText(a) == normalizeText(b) || TextSimilarity(a, b) >= threshold {
+				if ri, rj := find(i), find(j); ri != rj {
+					parent[rj] = ri
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range tasks {
+		root := find(i)
+		if len(members[root]) == 0 {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+	var clusters [][]string
+	for _, root := range roots {
+		group := members[root]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(a, b int) bool {
+			return tasks[group[a]].AddedAt.Before(tasks[group[b]].AddedAt)
+		})
+		ids := make([]string, len(group))
+		for k, idx := range group {
+			ids[k] = tasks[idx].ID
+		}
+		clusters = append(clusters, ids)
+	}
+	return clusters
+}
+
+// MergeDecisions keeps the oldest task of each duplicate cluster and emits a
+// merge decision for every other member, with its current values taken
+// from tasks.
+func MergeDecisions(clusters [][]string, tasks []Task) []Decision {
+	taskByID := make(map[string]Task, len(tasks))
+	for _, task := range tasks {
+		taskByID[task.ID] = task
+	}
+	var decisions []Decision
+	for _, cluster := range clusters {
+		for _, id := range cluster[1:] {
+			decisions = append(decisions, withFetchedValues(Decision{
+				TaskID:    id,
+				Action:    ActionMerge,
+				Reasoning: fmt.Sprintf("duplicate of task %s", cluster[0]),
+			}, taskByID[id]))
+		}
+	}
+	return decisions
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Subtask Deduplication", func() {
+	It("should drop exact duplicates after normalizing case and whitespace", func() {
+		subs := []string{"Write Intro", "  write   intro ", "Draft conclusion"}
+		Expect(DedupeSubtasks(subs)).To(Equal([]string{"Write Intro", "Draft conclusion"}))
+	})
+
+	It("should drop near-duplicates above the similarity threshold", func() {
+		subs := []string{"Write intro", "Write the introduction", "Edit the outro"}
+		Expect(DedupeSubtasks(subs)).To(Equal([]string{"Write intro", "Edit the outro"}))
+	})
+
+	It("should keep near-duplicates when fuzzy matching is disabled", func() {
+		Settings.SubtaskSimilarity = 0
+		subs := []string{"Write intro", "Write the introduction"}
+		Expect(DedupeSubtasks(subs)).To(HaveLen(2))
+	})
+
+	It("should dedupe subtasks before adding them on decompose", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+
+		ExecuteDecision(Decision{TaskID: "9", Action: "decompose", Subtasks: []string{"Write intro", "write intro"}})
+		Expect(mock.CalledCommands).To(Equal([][]string{
+			{"td", "task", "list", "--json", "--full"},
+			{"td", "task", "add", "Write intro", "--parent", "9"},
+		}))
+	})
+
+	It("should score unrelated phrases as dissimilar", func() {
+		Expect(TextSimilarity("Write intro", "Book flights")).To(BeZero())
+		Expect(TextSimilarity("Write intro", "write the introduction")).To(Equal(1.0))
+	})
+})
+
+var _ = Describe("Degenerate Subtasks", func() {
+	It("should drop a subtask that restates the parent and keep the rest", func() {
+		d := DropDegenerateSubtasks(Decision{TaskID: "9", Action: "decompose", Subtasks: []string{"Plan the trip", "Book flights"}}, "plan  the TRIP")
+		Expect(d.Action).To(Equal("decompose"))
+		Expect(d.Subtasks).To(Equal([]string{"Book flights"}))
+	})
+
+	It("should skip a decompose whose only subtask is the parent", func() {
+		d := DropDegenerateSubtasks(Decision{TaskID: "9", Action: "decompose", Subtasks: []string{"Plan the trip"}, Reasoning: "stale"}, "Plan the trip")
+		Expect(d.Action).To(Equal("skip"))
+		Expect(d.Subtasks).To(BeNil())
+		Expect(d.Reasoning).To(Equal("every subtask restates the task (was decompose: stale)"))
+	})
+
+	It("should catch restatements that differ only in stopwords or abbreviation", func() {
+		d := DropDegenerateSubtasks(Decision{TaskID: "9", Action: "decompose", Subtasks: []string{"Write the introduction."}}, "Write intro")
+		Expect(d.Action).To(Equal("skip"))
+	})
+
+	It("should keep subtasks that extend the parent's wording", func() {
+		d := DropDegenerateSubtasks(Decision{TaskID: "9", Action: "decompose", Subtasks: []string{"Write report introduction"}}, "Write report")
+		Expect(d.Subtasks).To(Equal([]string{"Write report introduction"}))
+
+		d = DropDegenerateSubtasks(Decision{TaskID: "9", Action: "decompose", Subtasks: []string{"Plan trip budget", "Plan trip itinerary"}}, "Plan trip")
+		Expect(d.Action).To(Equal("decompose"))
+		Expect(d.Subtasks).To(Equal([]string{"Plan trip budget", "Plan trip itinerary"}))
+	})
+
+	It("should drop only the decompose from a combined decision", func() {
+		priority := 2
+		d := withSubActions(Decision{TaskID: "9"}, []SubAction{
+			{Action: "reprioritize", Priority: &priority},
+			{Action: "decompose", Subtasks: []string{"Plan the trip"}},
+		})
+		d = DropDegenerateSubtasks(d, "Plan the trip")
+		Expect(d.Action).To(Equal("reprioritize"))
+		Expect(d.Actions).To(BeNil())
+		Expect(d.Subtasks).To(BeNil())
+	})
+
+	It("should validate the LLM's decompose against the task", func() {
+		Backend = backendFunc(func(string) (string, error) {
+			return `{"action": "decompose", "subtasks": ["Renew passport"], "reasoning": "stale"}`, nil
+		})
+		DeferCleanup(func() { Backend = defaultBackend() })
+
+		Expect(ProcessTask(Task{ID: "9", Content: "Renew passport"}, &InertiaContext{}).Action).To(Equal("skip"))
+	})
+})
+
+var _ = Describe("Duplicate Task Detection", func() {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{ID: "1", Content: "Renew passport", AddedAt: base.AddDate(0, 2, 0)},
+		{ID: "2", Content: "Call the plumber"},
+		{ID: "3", Content: "renew  the passport", AddedAt: base},
+		{ID: "4", Content: "Renew passport!", AddedAt: base.AddDate(0, 1, 0)},
+	}
+
+	It("should cluster near-identical tasks oldest first and leave distinct ones out", func() {
+		Expect(FindDuplicateTasks(tasks, 0.8)).To(Equal([][]string{{"3", "4", "1"}}))
+	})
+
+	It("should keep the oldest task and merge the rest", func() {
+		decisions := MergeDecisions(FindDuplicateTasks(tasks, 0.8), tasks)
+		Expect(decisions).To(HaveLen(2))
+		for i, id := range []string{"4", "1"} {
+			Expect(decisions[i].TaskID).To(Equal(id))
+			Expect(decisions[i].Action).To(Equal(ActionMerge))
+			Expect(decisions[i].Reasoning).To(Equal("duplicate of task 3"))
+		}
+	})
+})
+
+var _ = Describe("Match Normalization", func() {
+	It("should split camelCase words", func() {
+		Expect(NormalizeForMatch("FollowUpWithDana")).To(Equal("follow up with dana"))
+		Expect(NormalizeForMatch("Email HTTPServer owner")).To(Equal("email http server owner"))
+	})
+
+	It("should strip punctuation and emoji and collapse whitespace", func() {
+		Expect(NormalizeForMatch("  Call   Dana!! 📞🎉 (re: budget)")).To(Equal("call dana re budget"))
+	})
+})
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TaskSource is the task manager the engine reads from and writes to. The
+// command builders return a full argv for CommandRunner; priorities are td
+// strings ("p1" most urgent) whatever the source's native scale.
+type TaskSource interface {
+	// Binary names the CLI every command runs, for the preflight check.
+	Binary() string
+	FetchTasks() ([]Task, error)
+	ResolveProjectID(name string) (string, error)
+
+	PriorityCommand(id, priority string) []string
+	ContentCommand(id, content string) []string
+	// DueCommand clears the due date when due is empty.
+	DueCommand(id, due string) []string
+	// AddSubtaskCommand sets the given labels and priority on the new task
+	// too; nil labels and an empty priority leave the source's defaults.
+	AddSubtaskCommand(parentID, content string, labels []string, priority string) []string
+	ProjectCommand(id, projectID string) []string
+	// LabelsCommand replaces the task's labels with labels; none clears them.
+	LabelsCommand(id string, labels []string) []string
+}
+
+// Source is the active task source; main replaces it according to --source.
+var Source TaskSource = TDSource{}
+
+// NewTaskSource builds the source for --source. A comma-separated list such
+// as "td,taskwarrior" merges them through a MultiSource.
+func NewTaskSource(name string) (TaskSource, error) {
+	if names := strings.Split(name, ","); len(names) > 1 {
+		sources := make([]TaskSource, len(names))
+		for i, n := range names {
+			source, err := NewTaskSource(strings.TrimSpace(n))
+			if err != nil {
+				return nil, err
+			}
+			sources[i] = source
+		}
+		return NewMultiSource(sources)
+	}
+	switch name {
+	case "", "td":
+		return TDSource{}, nil
+	case "taskwarrior":
+		return TaskwarriorSource{}, nil
+	}
+	return nil, fmt.Errorf("unknown task source %q (want td or taskwarrior)", name)
+}
+
+// TDSource drives Todoist through the td CLI, running the commands in
+// Settings.CommandTemplates.
+type TDSource struct{}
+
+// templates are the active command templates.
+func (TDSource) templates() CommandTemplates {
+	return Settings.CommandTemplates.withDefaults()
+}
+
+func (s TDSource) Binary() string { return s.templates().Fetch[0] }
+
+// FetchTasks follows NextCursor through every page td returns.
+func (s TDSource) FetchTasks() ([]Task, error) {
+	var tasks []Task
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		cmd := RenderCommand(s.templates().Fetch, nil)
+		if cursor != "" {
+			cmd = append(cmd, "--cursor", cursor)
+		}
+		output, err := CommandRunner.Output(cmd[0], cmd[1:]...)
+		if err != nil {
+			return nil, fmt.Errorf("td command: %w", err)
+		}
+		page, err := parseTasksPage(output)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.Results...)
+		if page.NextCursor == "" {
+			return tasks, nil
+		}
+		if seen[page.NextCursor] {
+			return nil, fmt.Errorf("td returned cursor %q twice", page.NextCursor)
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+}
+
+// parseTasksResponse reads the `td task list --json` shape.
+func parseTasksResponse(data []byte) ([]Task, error) {
+	page, err := parseTasksPage(data)
+	return page.Results, err
+}
+
+func parseTasksPage(data []byte) (TasksResponse, error) {
+	var resp TasksResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return TasksResponse{}, fmt.Errorf("unmarshal tasks: %w", err)
+	}
+	return resp, nil
+}
+
+// FileSource reads tasks from a JSON file shaped like `td task list --json`
+// instead of running a command, for replays and deterministic tests. Every
+// other operation goes to the wrapped source.
+type FileSource struct {
+	TaskSource
+	Path string
+}
+
+func (s FileSource) FetchTasks() ([]Task, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read tasks file: %w", err)
+	}
+	return parseTasksResponse(data)
+}
+
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ProjectsResponse struct {
+	Results []Project `json:"results"`
+}
+
+func (s TDSource) ResolveProjectID(name string) (string, error) {
+	cmd := RenderCommand(s.templates().Projects, nil)
+	output, err := CommandRunner.Output(cmd[0], cmd[1:]...)
+	if err != nil {
+		return "", fmt.Errorf("td command: %w", err)
+	}
+	var resp ProjectsResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return "", fmt.Errorf("unmarshal projects: %w", err)
+	}
+	for _, project := range resp.Results {
+		if strings.EqualFold(project.Name, name) {
+			return project.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no project named %q", name)
+}
+
+// update renders the Update template for task id with extra appended.
+func (s TDSource) update(id string, extra ...string) []string {
+	return RenderCommand(s.templates().Update, map[string]string{"{id}": id}, extra...)
+}
+
+func (s TDSource) PriorityCommand(id, priority string) []string {
+	return s.update(id, "--priority", priority)
+}
+
+func (s TDSource) ContentCommand(id, content string) []string {
+	return s.update(id, "--content", content)
+}
+
+func (s TDSource) DueCommand(id, due string) []string {
+	return s.update(id, "--due", due)
+}
+
+func (s TDSource) AddSubtaskCommand(parentID, content string, labels []string, priority string) []string {
+	cmd := RenderCommand(s.templates().Add, map[string]string{"{content}": content, "{parent}": parentID})
+	if len(labels) > 0 {
+		cmd = append(cmd, "--labels", strings.Join(labels, ","))
+	}
+	if priority != "" {
+		cmd = append(cmd, "--priority", priority)
+	}
+	return cmd
+}
+
+func (s TDSource) ProjectCommand(id, projectID string) []string {
+	return RenderCommand(s.templates().Move, map[string]string{"{id}": id, "{project}": projectID})
+}
+
+func (s TDSource) LabelsCommand(id string, labels []string) []string {
+	return s.update(id, "--labels", strings.Join(labels, ","))
+}
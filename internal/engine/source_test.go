@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tasks File Source", func() {
+	const tasksJSON = `{"results": [
+		{"id": "1", "content": "Write report", "priority": 2, "addedAt": "2026-01-05T09:00:00Z", "labels": ["work"]},
+		{"id": "2", "content": "Gather figures", "parentId": "1", "projectId": "p9"}
+	]}`
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+		CommandRunner = mock
+		DeferCleanup(func() { Source = TDSource{} })
+	})
+
+	It("should produce the same tasks as the td output it mirrors, without running td", func() {
+		mock.Outputs["td"] = []byte(tasksJSON)
+		Source = TDSource{}
+		fromTD, err := FetchAllTasks()
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(GinkgoT().TempDir(), "tasks.json")
+		Expect(os.WriteFile(path, []byte(tasksJSON), 0644)).To(Succeed())
+		mock.CalledCommands = nil
+		Source = FileSource{TaskSource: TDSource{}, Path: path}
+		fromFile, err := FetchAllTasks()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fromFile).To(Equal(fromTD))
+		Expect(fromFile).To(HaveLen(2))
+		Expect(mock.CalledCommands).To(BeEmpty())
+	})
+
+	It("should still build update commands through the wrapped source", func() {
+		Source = FileSource{TaskSource: TDSource{}, Path: "unused.json"}
+		Expect(Source.DueCommand("1", "2026-03-01")).To(Equal([]string{"td", "task", "update", "1", "--due", "2026-03-01"}))
+	})
+
+	It("should report a missing file as a task fetch error", func() {
+		Source = FileSource{TaskSource: TDSource{}, Path: filepath.Join(GinkgoT().TempDir(), "missing.json")}
+		_, err := FetchAllTasks()
+		Expect(err).To(MatchError(ContainSubstring("read tasks file")))
+	})
+})
+
+// pagedRunner answers `td task list` with the page keyed by its --cursor
+// argument ("" for the first page).
+type pagedRunner struct {
+	*MockRunner
+	pages map[string]string
+}
+
+func (r pagedRunner) Output(name string, args ...string) ([]byte, error) {
+	r.MockRunner.Output(name, args...)
+	cursor := ""
+	if i := len(args) - 2; i >= 0 && args[i] == "--cursor" {
+		cursor = args[i+1]
+	}
+	return []byte(r.pages[cursor]), nil
+}
+
+var _ = Describe("Task Pagination", func() {
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+	})
+
+	It("should collect tasks from every page", func() {
+		CommandRunner = pagedRunner{mock, map[string]string{
+			"":    `{"results": [{"id": "1"}, {"id": "2"}], "nextCursor": "abc"}`,
+			"abc": `{"results": [{"id": "3"}], "nextCursor": "def"}`,
+			"def": `{"results": [{"id": "4"}]}`,
+		}}
+
+		tasks, err := FetchAllTasks()
+		Expect(err).NotTo(HaveOccurred())
+		var ids []string
+		for _, t := range tasks {
+			ids = append(ids, t.ID)
+		}
+		Expect(ids).To(Equal([]string{"1", "2", "3", "4"}))
+		Expect(mock.CalledCommands).To(HaveLen(3))
+		Expect(strings.Join(mock.CalledCommands[1], " ")).To(Equal("td task list --json --full --cursor abc"))
+	})
+
+	It("should stop when td repeats a cursor", func() {
+		CommandRunner = pagedRunner{mock, map[string]string{
+			"":    `{"results": [{"id": "1"}], "nextCursor": "abc"}`,
+			"abc": `{"results": [{"id": "2"}], "nextCursor": "abc"}`,
+		}}
+
+		_, err := FetchAllTasks()
+		Expect(err).To(MatchError(ContainSubstring(`cursor "abc" twice`)))
+	})
+})
+
+// flakyRunner fails the first `failures` calls with err, then defers to the
+// embedded mock.
+type flakyRunner struct {
+	*MockRunner
+	failures *int
+	err      error
+}
+
+func (r flakyRunner) Output(name string, args ...string) ([]byte, error) {
+	output, err := r.MockRunner.Output(name, args...)
+	if *r.failures > 0 {
+		*r.failures--
+		return nil, r.err
+	}
+	return output, err
+}
+
+var _ = Describe("Fetch Retries", func() {
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: map[string][]byte{"td": []byte(`{"results": [{"id": "1"}, {"id": "2"}]}`)}, Errors: map[string]error{}}
+	})
+
+	It("should return the tasks when a failed fetch succeeds on retry", func() {
+		failures := 1
+		CommandRunner = flakyRunner{mock, &failures, errors.New("connection reset by peer")}
+
+		tasks, err := FetchAllTasks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasks).To(HaveLen(2))
+		Expect(mock.CalledCommands).To(HaveLen(2))
+	})
+
+	It("should give up after --fetch-retries attempts", func() {
+		Settings.FetchRetries = 2
+		failures := 5
+		CommandRunner = flakyRunner{mock, &failures, errors.New("connection reset by peer")}
+
+		_, err := FetchAllTasks()
+		var fetchErr *TaskFetchError
+		Expect(errors.As(err, &fetchErr)).To(BeTrue())
+		Expect(mock.CalledCommands).To(HaveLen(3))
+	})
+
+	It("should not retry a rejected token", func() {
+		failures := 5
+		CommandRunner = flakyRunner{mock, &failures, &exec.ExitError{Stderr: []byte("Error: 401 Unauthorized")}}
+
+		_, err := FetchAllTasks()
+		Expect(err).To(HaveOccurred())
+		Expect(mock.CalledCommands).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// RunState is what --state-file carries between runs so frequent runs only
+// process tasks that changed: the newest UpdatedAt seen so far and the IDs
+// of every task a run has decided on.
+type RunState struct {
+	LastUpdatedAt time.Time       `json:"last_updated_at"`
+	Processed     map[string]bool `json:"processed,omitempty"`
+}
+
+// LoadRunState reads the state file at path, starting from an empty state,
+// which selects every task, if it doesn't exist yet.
+func LoadRunState(path string) (RunState, error) {
+	var state RunState
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("unmarshal state file: %w", err)
+	}
+	return state, nil
+}
+
+func SaveRunState(path string, state RunState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+	return nil
+}
+
+// FilterUpdatedSince keeps tasks updated after since. Tasks with no
+// UpdatedAt are kept, since there's no telling whether they changed, and a
+// zero since keeps everything.
+func FilterUpdatedSince(tasks []Task, since time.Time) []Task {
+	if since.IsZero() {
+		return tasks
+	}
+	var kept []Task
+	for _, task := range tasks {
+		if task.UpdatedAt.IsZero() || task.UpdatedAt.After(since) {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+// Pending keeps the tasks a run should look at: those updated since the
+// last run, plus any no run has decided on yet. Order is preserved.
+func (s RunState) Pending(tasks []Task) []Task {
+	changed := make(map[string]bool)
+	for _, task := range FilterUpdatedSince(tasks, s.LastUpdatedAt) {
+		changed[task.ID] = true
+	}
+	var kept []Task
+	for _, task := range tasks {
+		if changed[task.ID] || !s.Processed[task.ID] {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+// Advance records the decisions as processed and moves LastUpdatedAt up to
+// the newest UpdatedAt among fetched. Pass no tasks to record decisions
+// without moving the cursor, as when a run stopped before reaching every
+// changed task. A fallback skip after an LLM failure leaves its task
+// unprocessed, so the next run tries it again.
+func (s *RunState) Advance(fetched []Task, decisions []Decision) {
+	if s.Processed == nil {
+		s.Processed = make(map[string]bool)
+	}
+	for _, d := range decisions {
+		if d.LLMError != "" {
+			delete(s.Processed, d.TaskID)
+			continue
+		}
+		s.Processed[d.TaskID] = true
+	}
+	for _, task := range fetched {
+		if task.UpdatedAt.After(s.LastUpdatedAt) {
+			s.LastUpdatedAt = task.UpdatedAt
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Incremental Run State", func() {
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{ID: "old", UpdatedAt: since.Add(-time.Hour)},
+		{ID: "same", UpdatedAt: since},
+		{ID: "new", UpdatedAt: since.Add(time.Minute)},
+		{ID: "unknown"},
+	}
+
+	ids := func(tasks []Task) []string {
+		var out []string
+		for _, t := range tasks {
+			out = append(out, t.ID)
+		}
+		return out
+	}
+
+	It("should keep tasks updated after the cursor and those with no timestamp", func() {
+		Expect(ids(FilterUpdatedSince(tasks, since))).To(Equal([]string{"new", "unknown"}))
+	})
+
+	It("should keep everything without a cursor", func() {
+		Expect(FilterUpdatedSince(tasks, time.Time{})).To(HaveLen(4))
+	})
+
+	It("should also keep tasks no run has decided on", func() {
+		state := RunState{LastUpdatedAt: since, Processed: map[string]bool{"old": true, "same": true}}
+		Expect(ids(state.Pending(append(tasks, Task{ID: "fresh", UpdatedAt: since.Add(-48 * time.Hour)})))).
+			To(Equal([]string{"new", "unknown", "fresh"}))
+	})
+
+	It("should advance the cursor to the newest update and record decisions", func() {
+		var state RunState
+		state.Advance(tasks, []Decision{{TaskID: "new"}})
+		Expect(state.LastUpdatedAt).To(Equal(since.Add(time.Minute)))
+		Expect(state.Processed).To(Equal(map[string]bool{"new": true}))
+
+		state.Advance(nil, []Decision{{TaskID: "old"}})
+		Expect(state.LastUpdatedAt).To(Equal(since.Add(time.Minute)))
+		Expect(state.Processed).To(HaveKey("old"))
+	})
+
+	It("should keep a task whose LLM call failed pending for the next run", func() {
+		Backend = backendFunc(func(string) (string, error) { return "", errors.New("gateway timeout") })
+		DeferCleanup(func() { Backend = defaultBackend() })
+		decision := ProcessTask(tasks[2], &InertiaContext{})
+		Expect(decision.Action).To(Equal("skip"))
+		Expect(decision.LLMError).To(ContainSubstring("gateway timeout"))
+
+		state := RunState{Processed: map[string]bool{"new": true}}
+		state.Advance(tasks, []Decision{decision, {TaskID: "old"}})
+		Expect(state.LastUpdatedAt).To(Equal(since.Add(time.Minute)))
+		Expect(state.Processed).To(Equal(map[string]bool{"old": true}))
+		Expect(ids(state.Pending(tasks))).To(Equal([]string{"same", "new", "unknown"}))
+	})
+
+	It("should round-trip the state file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "state.json")
+		state := RunState{LastUpdatedAt: since, Processed: map[string]bool{"1": true, "2": true}}
+		Expect(SaveRunState(path, state)).To(Succeed())
+
+		loaded, err := LoadRunState(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.LastUpdatedAt.Equal(since)).To(BeTrue())
+		Expect(loaded.Processed).To(Equal(state.Processed))
+	})
+
+	It("should start empty when the state file doesn't exist", func() {
+		state, err := LoadRunState(filepath.Join(GinkgoT().TempDir(), "missing.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.LastUpdatedAt.IsZero()).To(BeTrue())
+		Expect(state.Pending(tasks)).To(HaveLen(4))
+	})
+})
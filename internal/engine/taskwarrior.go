@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// taskwarriorTime is the layout of Taskwarrior's exported timestamps.
+const taskwarriorTime = "20060102T150405Z"
+
+// TaskwarriorSource drives Taskwarrior through the task CLI. Tasks are
+// addressed by UUID, projects by name, and priority comes from urgency.
+// Taskwarrior has no parent links, so decompose adds standalone tasks.
+type TaskwarriorSource struct{}
+
+type taskwarriorAnnotation struct {
+	Description string `json:"description"`
+}
+
+type taskwarriorTask struct {
+	UUID        string                  `json:"uuid"`
+	Description string                  `json:"description"`
+	Annotations []taskwarriorAnnotation `json:"annotations"`
+	Entry       string                  `json:"entry"`
+	Modified    string                  `json:"modified"`
+	Urgency     float64                 `json:"urgency"`
+	Tags        []string                `json:"tags"`
+	Project     string                  `json:"project"`
+	Due         string                  `json:"due"`
+}
+
+func (TaskwarriorSource) Binary() string { return "task" }
+
+func (TaskwarriorSource) FetchTasks() ([]Task, error) {
+	output, err := CommandRunner.Output("task", "status:pending", "export")
+	if err != nil {
+		return nil, fmt.Errorf("task command: %w", err)
+	}
+	var exported []taskwarriorTask
+	if err := json.Unmarshal(output, &exported); err != nil {
+		return nil, fmt.Errorf("unmarshal taskwarrior export: %w", err)
+	}
+	tasks := make([]Task, len(exported))
+	for i, tw := range exported {
+		notes := make([]string, len(tw.Annotations))
+		for j, a := range tw.Annotations {
+			notes[j] = a.Description
+		}
+		// Unparseable timestamps stay zero, which the age filters treat as
+		// unknown.
+		added, _ := time.Parse(taskwarriorTime, tw.Entry)
+		updated, _ := time.Parse(taskwarriorTime, tw.Modified)
+		var due string
+		if t, err := time.Parse(taskwarriorTime, tw.Due); err == nil {
+			due = t.Format("2006-01-02")
+		}
+		tasks[i] = Task{
+			ID:          tw.UUID,
+			Content:     tw.Description,
+			Description: strings.Join(notes, "\n"),
+			Priority:    urgencyPriority(tw.Urgency, Settings.PriorityScale),
+			AddedAt:     added,
+			UpdatedAt:   updated,
+			Labels:      tw.Tags,
+			ProjectID:   tw.Project,
+			Due:         due,
+		}
+	}
+	return tasks, nil
+}
+
+// urgencyPriority buckets Taskwarrior urgency into 1-4 on scale.
+func urgencyPriority(urgency float64, scale PriorityScale) int {
+	level := 4
+	switch {
+	case urgency >= 10:
+		level = 1
+	case urgency >= 6:
+		level = 2
+	case urgency >= 3:
+		level = 3
+	}
+	if scale == PriorityAscending {
+		return 5 - level
+	}
+	return level
+}
+
+// ResolveProjectID returns name unchanged: Taskwarrior projects are
+// identified by name.
+func (TaskwarriorSource) ResolveProjectID(name string) (string, error) {
+	return name, nil
+}
+
+func (TaskwarriorSource) PriorityCommand(id, priority string) []string {
+	return []string{"task", id, "modify", "priority:" + taskwarriorPriority(priority)}
+}
+
+// taskwarriorPriority maps a td priority to H, M or L; p4 clears it.
+func taskwarriorPriority(priority string) string {
+	return map[string]string{"p1": "H", "p2": "M", "p3": "L"}[priority]
+}
+
+func (TaskwarriorSource) ContentCommand(id, content string) []string {
+	return []string{"task", id, "modify", "description:" + content}
+}
+
+func (TaskwarriorSource) DueCommand(id, due string) []string {
+	return []string{"task", id, "modify", "due:" + due}
+}
+
+func (TaskwarriorSource) AddSubtaskCommand(parentID, content string, labels []string, priority string) []string {
+	cmd := []string{"task", "add", content}
+	for _, label := range labels {
+		cmd = append(cmd, "+"+label)
+	}
+	if priority != "" {
+		cmd = append(cmd, "priority:"+taskwarriorPriority(priority))
+	}
+	return cmd
+}
+
+func (TaskwarriorSource) ProjectCommand(id, projectID string) []string {
+	return []string{"task", id, "modify", "project:" + projectID}
+}
+
+func (TaskwarriorSource) LabelsCommand(id string, labels []string) []string {
+	return []string{"task", id, "modify", "tags:" + strings.Join(labels, ",")}
+}
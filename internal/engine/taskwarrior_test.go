@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Taskwarrior Source", func() {
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: make(map[string][]byte), Errors: make(map[string]error)}
+		CommandRunner = mock
+		Source = TaskwarriorSource{}
+		DeferCleanup(func() { Source = TDSource{} })
+	})
+
+	It("should map exported Taskwarrior tasks onto Task", func() {
+		mock.Outputs["task"] = []byte(`[
+			{"id": 1, "uuid": "a1b2", "description": "Write newsletter", "entry": "20260110T083000Z",
+			 "modified": "20260201T120000Z", "urgency": 11.2, "tags": ["writing"], "project": "blog",
+			 "due": "20260305T000000Z",
+			 "annotations": [{"entry": "20260111T000000Z", "description": "Issue 12"}]},
+			{"id": 2, "uuid": "c3d4", "description": "Fix gate", "entry": "20251201T000000Z", "urgency": 1.5}
+		]`)
+
+		tasks, err := FetchAllTasks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mock.CalledCommands).To(Equal([][]string{{"task", "status:pending", "export"}}))
+		Expect(tasks).To(HaveLen(2))
+		Expect(tasks[0].ID).To(Equal("a1b2"))
+		Expect(tasks[0].Content).To(Equal("Write newsletter"))
+		Expect(tasks[0].Description).To(Equal("Issue 12"))
+		Expect(tasks[0].Priority).To(Equal(1))
+		Expect(tasks[0].AddedAt).To(Equal(time.Date(2026, 1, 10, 8, 30, 0, 0, time.UTC)))
+		Expect(tasks[0].UpdatedAt).To(Equal(time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)))
+		Expect(tasks[0].Labels).To(Equal([]string{"writing"}))
+		Expect(tasks[0].ProjectID).To(Equal("blog"))
+		Expect(tasks[0].Due).To(Equal("2026-03-05"))
+		Expect(tasks[1].Due).To(BeEmpty())
+		Expect(tasks[1].Priority).To(Equal(4))
+		Expect(tasks[1].ParentID).To(BeNil())
+	})
+
+	It("should follow the priority scale when mapping urgency", func() {
+		Settings.PriorityScale = PriorityAscending
+		Expect(urgencyPriority(11.2, Settings.PriorityScale)).To(Equal(4))
+		Expect(urgencyPriority(4, Settings.PriorityScale)).To(Equal(2))
+	})
+
+	It("should execute decisions with task modify and task add", func() {
+		priority, content, due := 1, "Draft newsletter intro", "2026-03-03"
+		ExecuteDecision(Decision{TaskID: "a1b2", Action: "reprioritize", Priority: &priority, CurrentPriority: 3})
+		ExecuteDecision(Decision{TaskID: "a1b2", Action: "recontextualize", NewContent: &content})
+		ExecuteDecision(Decision{TaskID: "a1b2", Action: "defer", Due: &due})
+		mock.Outputs["task"] = []byte(`[]`)
+		ExecuteDecision(Decision{TaskID: "a1b2", Action: "decompose", Subtasks: []string{"Pick topic"}, CurrentLabels: []string{"blog"}, CurrentPriority: 2})
+
+		Expect(mock.CalledCommands).To(Equal([][]string{
+			{"task", "a1b2", "modify", "priority:H"},
+			{"task", "a1b2", "modify", "description:Draft newsletter intro"},
+			{"task", "a1b2", "modify", "due:2026-03-03"},
+			{"task", "status:pending", "export"},
+			{"task", "add", "Pick topic", "+blog", "priority:M"},
+		}))
+	})
+
+	It("should be selected by name", func() {
+		source, err := NewTaskSource("taskwarrior")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(source.Binary()).To(Equal("task"))
+		_, err = NewTaskSource("jira")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,65 @@
+package engine
+
+import "strings"
+
+// CommandTemplates are the argvs TDSource runs, for a td that has been
+// wrapped or aliased with other verbs (say "ls" for "list"). Within each
+// argument {id}, {content}, {parent} and {project} stand for the task's
+// values; TDSource appends its own flags, such as --priority p1 or
+// --cursor, after the rendered template. An empty template falls back to
+// the default.
+type CommandTemplates struct {
+	// Fetch lists every task as JSON.
+	Fetch []string `json:"fetch"`
+	// Projects lists every project as JSON.
+	Projects []string `json:"projects"`
+	// Update edits task {id}; the field to change is appended.
+	Update []string `json:"update"`
+	// Add creates {content} as a subtask of {parent}.
+	Add []string `json:"add"`
+	// Move puts task {id} in project {project}.
+	Move []string `json:"move"`
+}
+
+func DefaultCommandTemplates() CommandTemplates {
+	return CommandTemplates{
+		Fetch:    []string{"td", "task", "list", "--json", "--full"},
+		Projects: []string{"td", "project", "list", "--json"},
+		Update:   []string{"td", "task", "update", "{id}"},
+		Add:      []string{"td", "task", "add", "{content}", "--parent", "{parent}"},
+		Move:     []string{"td", "task", "update", "{id}", "--project", "{project}"},
+	}
+}
+
+// withDefaults fills in the templates t leaves empty.
+func (t CommandTemplates) withDefaults() CommandTemplates {
+	defaults := DefaultCommandTemplates()
+	for _, pair := range []struct{ tmpl, def *[]string }{
+		{&t.Fetch, &defaults.Fetch},
+		{&t.Projects, &defaults.Projects},
+		{&t.Update, &defaults.Update},
+		{&t.Add, &defaults.Add},
+		{&t.Move, &defaults.Move},
+	} {
+		if len(*pair.tmpl) == 0 {
+			*pair.tmpl = *pair.def
+		}
+	}
+	return t
+}
+
+// RenderCommand returns template with each placeholder in vars, such as
+// "{id}", replaced by its value, followed by extra. A value is never
+// expanded again, so task text containing "{id}" passes through intact.
+func RenderCommand(template []string, vars map[string]string, extra ...string) []string {
+	pairs := make([]string, 0, 2*len(vars))
+	for placeholder, value := range vars {
+		pairs = append(pairs, placeholder, value)
+	}
+	r := strings.NewReplacer(pairs...)
+	cmd := make([]string, 0, len(template)+len(extra))
+	for _, arg := range template {
+		cmd = append(cmd, r.Replace(arg))
+	}
+	return append(cmd, extra...)
+}
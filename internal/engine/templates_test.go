@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Command Templates", func() {
+	var mock *MockRunner
+
+	BeforeEach(func() {
+		mock = &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		Settings.CommandTemplates = CommandTemplates{
+			Fetch:  []string{"todo", "ls", "--format=json"},
+			Update: []string{"todo", "edit", "{id}"},
+			Add:    []string{"todo", "new", "--under={parent}", "{content}"},
+			Move:   []string{"todo", "mv", "{id}", "{project}"},
+		}
+	})
+
+	It("should build td's argvs from the configured templates", func() {
+		source := TDSource{}
+		Expect(source.Binary()).To(Equal("todo"))
+		Expect(source.PriorityCommand("42", "p1")).To(Equal([]string{"todo", "edit", "42", "--priority", "p1"}))
+		Expect(source.ContentCommand("42", "Call {id}")).To(Equal([]string{"todo", "edit", "42", "--content", "Call {id}"}))
+		Expect(source.AddSubtaskCommand("42", "Draft outline", []string{"work"}, "p2")).
+			To(Equal([]string{"todo", "new", "--under=42", "Draft outline", "--labels", "work", "--priority", "p2"}))
+		Expect(source.ProjectCommand("42", "9")).To(Equal([]string{"todo", "mv", "42", "9"}))
+	})
+
+	It("should fetch with the fetch template and fall back to defaults for the rest", func() {
+		mock.Outputs["todo"] = []byte(`{"results": [{"id": "1", "content": "Water plants"}]}`)
+		mock.Outputs["td"] = []byte(`{"results": [{"id": "7", "name": "Ice Box"}]}`)
+
+		tasks, err := TDSource{}.FetchTasks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasks).To(HaveLen(1))
+		id, err := TDSource{}.ResolveProjectID("ice box")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("7"))
+		Expect(mock.CalledCommands).To(Equal([][]string{
+			{"todo", "ls", "--format=json"},
+			{"td", "project", "list", "--json"},
+		}))
+	})
+
+	It("should read templates from the config file, keeping defaults for the ones it omits", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "config.json")
+		Expect(os.WriteFile(path, []byte(`{"command_templates": {"update": ["mytd", "task", "set", "{id}"]}}`), 0644)).To(Succeed())
+		cfg, err := LoadConfig(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.CommandTemplates.Update).To(Equal([]string{"mytd", "task", "set", "{id}"}))
+		Expect(cfg.CommandTemplates.Fetch).To(Equal(DefaultCommandTemplates().Fetch))
+	})
+})
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	UndoFieldPriority = "priority"
+	UndoFieldContent  = "content"
+	// UndoFieldProject entries record an ice-box move out of the task's
+	// project. An empty OldValue means the project was unknown.
+	UndoFieldProject = "project"
+	// UndoFieldDue entries record a defer. An empty OldValue means the task
+	// had no due date, so undoing clears it.
+	UndoFieldDue = "due"
+	// UndoFieldLabels entries record an ice-box label: OldValue is the task's
+	// labels before it, comma-separated, and NewValue the label added.
+	UndoFieldLabels = "labels"
+	// UndoFieldSubtask entries record subtasks added by decompose. td doesn't
+	// report the new task's ID, so these can't be inverted automatically.
+	UndoFieldSubtask = "subtask"
+)
+
+type UndoEntry struct {
+	TaskID   string    `json:"task_id"`
+	Field    string    `json:"field"`
+	OldValue string    `json:"old_value"`
+	NewValue string    `json:"new_value"`
+	At       time.Time `json:"at"`
+	// SourceID is the source the task came from when several are merged,
+	// so the inverse goes back to it; see MultiSource.
+	SourceID string `json:"source_id,omitempty"`
+}
+
+// UndoLog collects the mutations applied during a run.
+type UndoLog struct {
+	mu      sync.Mutex
+	Entries []UndoEntry
+}
+
+// UndoRecorder receives an entry for every successful mutation when set.
+var UndoRecorder *UndoLog
+
+func recordUndo(decision Decision, field, oldValue, newValue string) {
+	if UndoRecorder == nil {
+		return
+	}
+	UndoRecorder.mu.Lock()
+	defer UndoRecorder.mu.Unlock()
+	UndoRecorder.Entries = append(UndoRecorder.Entries, UndoEntry{
+		TaskID:   decision.TaskID,
+		SourceID: decision.SourceID,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+		At:       NowFunc(),
+	})
+}
+
+func (l *UndoLog) Write(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	data, err := json.MarshalIndent(l.Entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal undo log: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write undo log: %w", err)
+	}
+	return nil
+}
+
+func ReadUndoLog(path string) ([]UndoEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read undo log: %w", err)
+	}
+	var entries []UndoEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("unmarshal undo log: %w", err)
+	}
+	return entries, nil
+}
+
+// CanUndo reports whether BuildUndoCommands can invert e.
+func CanUndo(e UndoEntry) bool {
+	switch e.Field {
+	case UndoFieldPriority, UndoFieldContent, UndoFieldDue, UndoFieldLabels:
+		return true
+	case UndoFieldProject:
+		return e.OldValue != ""
+	}
+	return false
+}
+
+// BuildUndoCommands returns the Source commands restoring each entry's old
+// value, newest mutation first. Entries CanUndo rejects, such as added
+// subtasks, are left out.
+func BuildUndoCommands(entries []UndoEntry) [][]string {
+	var commands [][]string
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !CanUndo(e) {
+			continue
+		}
+		routeTask(e.TaskID, e.SourceID)
+		switch e.Field {
+		case UndoFieldPriority:
+			commands = append(commands, Source.PriorityCommand(e.TaskID, e.OldValue))
+		case UndoFieldContent:
+			commands = append(commands, Source.ContentCommand(e.TaskID, e.OldValue))
+		case UndoFieldProject:
+			commands = append(commands, Source.ProjectCommand(e.TaskID, e.OldValue))
+		case UndoFieldDue:
+			commands = append(commands, Source.DueCommand(e.TaskID, e.OldValue))
+		case UndoFieldLabels:
+			var labels []string
+			if e.OldValue != "" {
+				labels = strings.Split(e.OldValue, ",")
+			}
+			commands = append(commands, Source.LabelsCommand(e.TaskID, labels))
+		}
+	}
+	return commands
+}
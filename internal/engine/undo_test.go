@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Undo Log", func() {
+	It("should invert reprioritize and recontextualize entries, newest first", func() {
+		entries := []UndoEntry{
+			{TaskID: "1", Field: UndoFieldPriority, OldValue: "p4", NewValue: "p1"},
+			{TaskID: "2", Field: UndoFieldContent, OldValue: "Sort garage", NewValue: "Clear garage shelf"},
+			{TaskID: "3", Field: UndoFieldSubtask, NewValue: "Buy boxes"},
+		}
+
+		Expect(BuildUndoCommands(entries)).To(Equal([][]string{
+			{"td", "task", "update", "2", "--content", "Sort garage"},
+			{"td", "task", "update", "1", "--priority", "p4"},
+		}))
+	})
+
+	It("should move an ice-boxed task back to its project", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		UndoRecorder = &UndoLog{}
+		IceBox = ProjectIceBox{ProjectID: "99"}
+		DeferCleanup(func() { UndoRecorder, IceBox = nil, ProjectIceBox{} })
+
+		ExecuteDecision(Decision{TaskID: "1", Action: "ice-box", CurrentProjectID: "7"})
+		ExecuteDecision(Decision{TaskID: "2", Action: ActionMerge})
+		Expect(UndoRecorder.Entries).To(HaveLen(2))
+		Expect(UndoRecorder.Entries[0].OldValue).To(Equal("7"))
+		Expect(UndoRecorder.Entries[0].NewValue).To(Equal("99"))
+
+		Expect(CanUndo(UndoRecorder.Entries[1])).To(BeFalse())
+		Expect(BuildUndoCommands(UndoRecorder.Entries)).To(Equal([][]string{
+			{"td", "task", "update", "1", "--project", "7"},
+		}))
+	})
+
+	It("should restore the labels a task had before the ice-box label", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		UndoRecorder = &UndoLog{}
+		IceBox = LabelIceBox{Label: "icebox"}
+		DeferCleanup(func() { UndoRecorder, IceBox = nil, ProjectIceBox{} })
+
+		ExecuteDecision(Decision{TaskID: "1", Action: "ice-box", CurrentLabels: []string{"home", "errand"}})
+		ExecuteDecision(Decision{TaskID: "2", Action: "ice-box"})
+		Expect(UndoRecorder.Entries).To(HaveLen(2))
+		Expect(UndoRecorder.Entries[0].Field).To(Equal(UndoFieldLabels))
+		Expect(UndoRecorder.Entries[0].OldValue).To(Equal("home,errand"))
+		Expect(BuildUndoCommands(UndoRecorder.Entries)).To(Equal([][]string{
+			{"td", "task", "update", "2", "--labels", ""},
+			{"td", "task", "update", "1", "--labels", "home,errand"},
+		}))
+
+		Source = TaskwarriorSource{}
+		DeferCleanup(func() { Source = TDSource{} })
+		Expect(BuildUndoCommands(UndoRecorder.Entries[:1])).To(Equal([][]string{{"task", "1", "modify", "tags:home,errand"}}))
+	})
+
+	It("should restore or clear a deferred task's due date", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		UndoRecorder = &UndoLog{}
+		DeferCleanup(func() { UndoRecorder = nil })
+
+		due := "2026-04-01"
+		ExecuteDecision(Decision{TaskID: "1", Action: "defer", Due: &due, CurrentDue: "2026-03-01"})
+		ExecuteDecision(Decision{TaskID: "2", Action: "defer", Due: &due})
+		Expect(UndoRecorder.Entries).To(HaveLen(2))
+		Expect(UndoRecorder.Entries[0].Field).To(Equal(UndoFieldDue))
+		Expect(BuildUndoCommands(UndoRecorder.Entries)).To(Equal([][]string{
+			{"td", "task", "update", "2", "--due", ""},
+			{"td", "task", "update", "1", "--due", "2026-03-01"},
+		}))
+	})
+
+	It("should record executed mutations with their previous values", func() {
+		mock := &MockRunner{Outputs: map[string][]byte{}, Errors: map[string]error{}}
+		CommandRunner = mock
+		UndoRecorder = &UndoLog{}
+		DeferCleanup(func() { UndoRecorder = nil })
+
+		priority := 1
+		content := "Clear garage shelf"
+		ExecuteDecision(Decision{TaskID: "1", Action: "reprioritize", Priority: &priority, CurrentPriority: 4})
+		ExecuteDecision(Decision{TaskID: "2", Action: "recontextualize", NewContent: &content, CurrentContent: "Sort garage"})
+
+		path := filepath.Join(GinkgoT().TempDir(), "undo.json")
+		Expect(UndoRecorder.Write(path)).To(Succeed())
+		entries, err := ReadUndoLog(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].TaskID).To(Equal("1"))
+		Expect(entries[0].OldValue).To(Equal("p4"))
+		Expect(entries[0].NewValue).To(Equal("p1"))
+		Expect(entries[1].TaskID).To(Equal("2"))
+		Expect(entries[1].OldValue).To(Equal("Sort garage"))
+	})
+})
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidationError lists everything wrong with a context file at once, so a
+// broken phase 1 artifact can be fixed in one pass.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid context: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateContext checks the fields the engine relies on after unmarshalling.
+func ValidateContext(ctx *InertiaContext) error {
+	var problems []string
+	if ctx.Date == "" {
+		problems = append(problems, `missing required field "date"`)
+	} else if _, err := time.Parse("2006-01-02", ctx.Date); err != nil {
+		problems = append(problems, fmt.Sprintf(`"date" %q is not YYYY-MM-DD`, ctx.Date))
+	}
+	sections := []struct {
+		name     string
+		entities []Entity
+	}{
+		{"people", ctx.Gazetteer.People},
+		{"projects", ctx.Gazetteer.Projects},
+		{"places", ctx.Gazetteer.Places},
+		{"concepts", ctx.Gazetteer.Concepts},
+	}
+	for _, section := range sections {
+		for i, entity := range section.entities {
+			if strings.TrimSpace(entity.Name) == "" {
+				problems = append(problems, fmt.Sprintf(`gazetteer.%s[%d] is missing "name"`, section.name, i))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkContextTypes reports fields whose JSON type doesn't match the schema.
+// It runs before unmarshalling, which would otherwise stop at the first
+// mismatch with a message naming Go types rather than context fields.
+func checkContextTypes(data []byte) []string {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return []string{fmt.Sprintf("context is not a JSON object: %v", err)}
+	}
+	var problems []string
+	expect := func(path string, raw json.RawMessage, kind byte, kindName string) bool {
+		trimmed := bytes.TrimSpace(raw)
+		if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+			return false
+		}
+		if trimmed[0] != kind {
+			problems = append(problems, fmt.Sprintf("%q must be %s", path, kindName))
+			return false
+		}
+		return true
+	}
+
+	expect("date", root["date"], '"', "a string")
+	expect("state", root["state"], '{', "an object")
+	if expect("gazetteer", root["gazetteer"], '{', "an object") {
+		var gazetteer map[string]json.RawMessage
+		json.Unmarshal(root["gazetteer"], &gazetteer)
+		for _, section := range []string{"people", "projects", "places", "concepts"} {
+			expect("gazetteer."+section, gazetteer[section], '[', "an array")
+		}
+	}
+	if expect("intentions", root["intentions"], '{', "an object") {
+		var intentions map[string]json.RawMessage
+		json.Unmarshal(root["intentions"], &intentions)
+		for _, kind := range []string{"explicit", "implicit"} {
+			expect("intentions."+kind, intentions[kind], '[', "an array")
+		}
+	}
+	return problems
+}
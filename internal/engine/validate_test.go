@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Context Validation", func() {
+	writeContext := func(body string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "context.json")
+		Expect(os.WriteFile(path, []byte(body), 0644)).To(Succeed())
+		return path
+	}
+
+	It("should load a valid context file", func() {
+		path := writeContext(`{
+			"date": "2026-02-22",
+			"gazetteer": {"people": [{"name": "Dana", "context": "friend"}], "concepts": []},
+			"state": {"energy": "high"},
+			"intentions": {"explicit": ["write"], "implicit": []}
+		}`)
+
+		ctx, err := LoadContext(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ctx.Gazetteer.People).To(HaveLen(1))
+	})
+
+	It("should load a context from a reader", func() {
+		ctx, err := LoadContextFrom(strings.NewReader(`{"date": "2026-02-22", "state": {"energy": "low"}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ctx.Date).To(Equal("2026-02-22"))
+		Expect(ctx.State.Energy).To(Equal("low"))
+	})
+
+	It("should tell empty input apart from malformed JSON", func() {
+		_, err := LoadContextFrom(strings.NewReader("  \n"))
+		Expect(err).To(MatchError(ErrEmptyContext))
+
+		_, err = LoadContextFrom(strings.NewReader(`{"date": "2026-02-22",`))
+		Expect(err).NotTo(MatchError(ErrEmptyContext))
+		Expect(err.Error()).To(ContainSubstring("not a JSON object"))
+	})
+
+	It("should reject a context with no date", func() {
+		path := writeContext(`{"gazetteer": {"people": []}}`)
+
+		_, err := LoadContext(path)
+		var validationErr *ValidationError
+		Expect(errors.As(err, &validationErr)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`missing required field "date"`))
+	})
+
+	It("should name a gazetteer section with the wrong type", func() {
+		path := writeContext(`{"date": "2026-02-22", "gazetteer": {"people": {"name": "Dana"}, "concepts": "none"}}`)
+
+		_, err := LoadContext(path)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`"gazetteer.people" must be an array`))
+		Expect(err.Error()).To(ContainSubstring(`"gazetteer.concepts" must be an array`))
+	})
+
+	It("should report entities without names", func() {
+		err := ValidateContext(&InertiaContext{
+			Date:      "2026-02-22",
+			Gazetteer: Gazetteer{Concepts: []Entity{{Name: "Writing"}, {Context: "orphan"}}},
+		})
+		Expect(err).To(MatchError(ContainSubstring(`gazetteer.concepts[1] is missing "name"`)))
+	})
+})
@@ -1,28 +1,107 @@
 package runner
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
+// CommandRunner runs external commands. The engine calls it from many
+// goroutines at once, so implementations must be safe for concurrent use.
 type CommandRunner interface {
 	Run(name string, args ...string) error
 	Output(name string, args ...string) ([]byte, error)
 	RunWithStdin(stdin string, name string, args ...string) ([]byte, error)
 }
 
-type RealRunner struct{}
+// StreamRunner is a CommandRunner that can also hand back a command's stdout
+// while it is still running. Closing the stream before it reaches EOF kills
+// the command.
+type StreamRunner interface {
+	CommandRunner
+	RunWithStdinStream(stdin string, name string, args ...string) (io.ReadCloser, error)
+}
+
+// RealRunner builds a fresh exec.Cmd for each call. Env is read-only after
+// construction, so a RealRunner is safe to share.
+type RealRunner struct {
+	// Env maps a command name to KEY=VALUE pairs added on top of the
+	// parent environment whenever that command runs.
+	Env map[string][]string
+}
+
+func (r *RealRunner) command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	if env := r.Env[name]; len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
 
 func (r *RealRunner) Run(name string, args ...string) error {
-	return exec.Command(name, args...).Run()
+	return r.command(name, args...).Run()
 }
 
 func (r *RealRunner) Output(name string, args ...string) ([]byte, error) {
-	return exec.Command(name, args...).Output()
+	return r.command(name, args...).Output()
 }
 
 func (r *RealRunner) RunWithStdin(stdin string, name string, args ...string) ([]byte, error) {
-	cmd := exec.Command(name, args...)
+	cmd := r.command(name, args...)
 	cmd.Stdin = strings.NewReader(stdin)
 	return cmd.Output()
 }
+
+func (r *RealRunner) RunWithStdinStream(stdin string, name string, args ...string) (io.ReadCloser, error) {
+	cmd := r.command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	s := &cmdStream{ReadCloser: stdout, cmd: cmd}
+	cmd.Stderr = &s.stderr
+	// Don't let a grandchild still holding stderr stall Close after a kill.
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// cmdStream is a running command's stdout.
+type cmdStream struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr bytes.Buffer
+	eof    bool
+}
+
+func (s *cmdStream) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if err == io.EOF {
+		s.eof = true
+	}
+	return n, err
+}
+
+// Close waits for the command, killing it first if its output wasn't read to
+// the end. Only a command that ran to completion can report an error, which
+// like Output's carries its stderr.
+func (s *cmdStream) Close() error {
+	if !s.eof {
+		s.cmd.Process.Kill()
+		s.cmd.Wait()
+		return nil
+	}
+	err := s.cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Stderr = s.stderr.Bytes()
+	}
+	return err
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gavmor/inertia-engine/internal/engine"
+	"github.com/gavmor/inertia-engine/internal/runner"
+)
+
+func main() {
+	started := time.Now()
+	args := os.Args[1:]
+	var subcommand string
+	if len(args) > 0 && (args[0] == "icebox" || args[0] == "selftest") {
+		subcommand, args = args[0], args[1:]
+	}
+	iceBoxOnly := subcommand == "icebox"
+	cfg, err := engine.ParseFlags(args)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	engine.Settings = cfg
+	engine.CommandRunner = &runner.RealRunner{Env: cfg.CommandEnv()}
+
+	if cfg.Output != "text" && cfg.Output != "json" {
+		log.Fatalf("unknown --output %q (want text or json)", cfg.Output)
+	}
+
+	engine.Log, err = engine.NewLoggerFromConfig(os.Stderr, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	engine.Source, err = engine.NewTaskSource(cfg.Source)
+	if err != nil {
+		fatal("%v", err)
+	}
+	if cfg.TasksFile != "" {
+		engine.Source = engine.FileSource{TaskSource: engine.Source, Path: cfg.TasksFile}
+	}
+	engine.Backend, err = engine.NewBackend(cfg)
+	if err != nil {
+		fatal("%v", err)
+	}
+	if _, err := engine.ParsePriorityScale(string(cfg.PriorityScale)); err != nil {
+		fatal("%v", err)
+	}
+	engine.ActiveScorer, err = engine.NewScorer(cfg.Scorer)
+	if err != nil {
+		fatal("%v", err)
+	}
+	engine.Aggregator, err = engine.NewWeightAggregator(cfg.WeightAggregator)
+	if err != nil {
+		fatal("%v", err)
+	}
+
+	if subcommand == "selftest" {
+		result := engine.RunSelfTest(engine.CommandRunner, *cfg)
+		fmt.Print(result.String())
+		if !result.Passed() {
+			os.Exit(1)
+		}
+		return
+	}
+	if cfg.Undo != "" {
+		runUndo(cfg.Undo)
+		return
+	}
+	if cfg.Resume != "" {
+		runResume(cfg.Resume)
+		return
+	}
+	if iceBoxOnly || cfg.PrintPrompt != "" {
+		// Neither the sweep nor --print-prompt calls the LLM, so don't
+		// require one.
+		engine.Backend = nil
+	}
+
+	if !cfg.SkipPreflight {
+		if err := engine.PreflightCheck(engine.CommandRunner); err != nil {
+			fatal("%v", err)
+		}
+	}
+
+	if cfg.Context == "" {
+		fatal("--context is required")
+	}
+
+	inertiaCtx, err := engine.LoadContext(cfg.Context)
+	if err != nil {
+		fatal("%v", err)
+	}
+	if cfg.Deterministic {
+		// Ages and relative due dates follow the context's date, not the
+		// wall clock, so a replay on another day sees the same prompts.
+		day, err := time.ParseInLocation("2006-01-02", inertiaCtx.Date, time.Local)
+		if err != nil {
+			fatal("--deterministic needs a context date: %v", err)
+		}
+		engine.NowFunc = func() time.Time { return day }
+		if cfg.Explain == "" {
+			engine.Log.Warn("--deterministic without --explain: prompts and responses are not recorded for replay")
+		}
+	}
+
+	if cfg.Examples != "" {
+		if engine.Examples, err = engine.LoadExamples(cfg.Examples); err != nil {
+			fatal("Failed to load examples: %v", err)
+		}
+	}
+
+	if cfg.Anonymize {
+		engine.AnonymizePeople = inertiaCtx.Gazetteer.People
+	}
+
+	var prior *engine.RunReport
+	if cfg.Compare != "" {
+		report, err := engine.LoadReport(cfg.Compare)
+		if err != nil {
+			fatal("Failed to load --compare report: %v", err)
+		}
+		prior = &report
+	}
+
+	if cfg.Cache != "" && !cfg.NoCache {
+		engine.Cache, err = engine.LoadDecisionCache(cfg.Cache)
+		if err != nil {
+			fatal("Failed to load decision cache: %v", err)
+		}
+	}
+
+	tasks, err := engine.FetchAllTasks()
+	if err != nil {
+		fatal("%v", err)
+	}
+	if cfg.PrintPrompt != "" {
+		prompt, err := engine.PromptForTask(tasks, cfg.PrintPrompt, inertiaCtx)
+		if err != nil {
+			fatal("%v", err)
+		}
+		fmt.Print(prompt)
+		return
+	}
+
+	leafTasks := engine.FilterLeafNodes(tasks)
+	if err := engine.CheckMinTasks(len(leafTasks), cfg.MinTasks, cfg.AllowEmpty); err != nil {
+		fatal("%v", err)
+	}
+	var filtered []engine.Decision
+	if cfg.ReportFiltered {
+		filtered = append(filtered, engine.FilteredDecisions(tasks, leafTasks, "non-leaf task")...)
+	}
+
+	var active []engine.Task
+	iceBoxedReason := "already in ice-box project"
+	if cfg.IceBoxStrategy == "label" {
+		active = engine.ExcludeByLabels(leafTasks, []string{cfg.IceBoxLabel})
+		iceBoxedReason = "already labelled " + cfg.IceBoxLabel
+	} else {
+		if cfg.IceBoxProject == "" {
+			if cfg.IceBoxProject, err = engine.ResolveProjectID(cfg.IceBoxName); err != nil {
+				engine.Log.Warn("Could not resolve ice-box project %q, not excluding ice-boxed tasks: %v", cfg.IceBoxName, err)
+			}
+		}
+		active = engine.ExcludeIceBoxed(leafTasks, cfg.IceBoxProject)
+	}
+	if engine.IceBox, err = engine.NewIceBoxStrategy(cfg.IceBoxStrategy, cfg.IceBoxProject, cfg.IceBoxLabel); err != nil {
+		fatal("%v", err)
+	}
+	if skipped := len(leafTasks) - len(active); skipped > 0 {
+		engine.Log.Info("Skipping %d tasks already in the ice-box", skipped)
+		if cfg.ReportFiltered {
+			filtered = append(filtered, engine.FilteredDecisions(leafTasks, active, iceBoxedReason)...)
+		}
+	}
+	leafTasks = active
+	if len(cfg.ExcludeLabels) > 0 {
+		kept := engine.ExcludeByLabels(leafTasks, cfg.ExcludeLabels)
+		engine.Log.Info("Skipping %d tasks labelled %s", len(leafTasks)-len(kept), cfg.ExcludeLabels)
+		if cfg.ReportFiltered {
+			filtered = append(filtered, engine.FilteredDecisions(leafTasks, kept, "excluded label")...)
+		}
+		leafTasks = kept
+	}
+
+	if iceBoxOnly {
+		runIceBox(cfg, leafTasks, inertiaCtx)
+		return
+	}
+	var runState engine.RunState
+	if cfg.StateFile != "" {
+		if runState, err = engine.LoadRunState(cfg.StateFile); err != nil {
+			fatal("Failed to load state file: %v", err)
+		}
+		pending := runState.Pending(leafTasks)
+		engine.Log.Info("Skipping %d tasks unchanged since %s", len(leafTasks)-len(pending), runState.LastUpdatedAt.Format(time.RFC3339))
+		if cfg.ReportFiltered {
+			filtered = append(filtered, engine.FilteredDecisions(leafTasks, pending, "unchanged since last run")...)
+		}
+		leafTasks = pending
+	}
+	if cfg.MinAge > 0 {
+		stale := engine.FilterByAge(leafTasks, time.Duration(cfg.MinAge), engine.NowFunc())
+		engine.Log.Info("Skipping %d tasks younger than %s", len(leafTasks)-len(stale), cfg.MinAge)
+		if cfg.ReportFiltered {
+			filtered = append(filtered, engine.FilteredDecisions(leafTasks, stale, "younger than min-age")...)
+		}
+		leafTasks = stale
+	}
+	duplicates := engine.FindDuplicateTasks(leafTasks, cfg.DuplicateSimilarity)
+	for _, cluster := range duplicates {
+		engine.Log.Info("Possible duplicates: %s", strings.Join(cluster, ", "))
+	}
+	var merges []engine.Decision
+	if cfg.MergeDuplicates {
+		merges = engine.MergeDecisions(duplicates, leafTasks)
+		merged := make(map[string]bool, len(merges))
+		for _, d := range merges {
+			merged[d.TaskID] = true
+		}
+		var kept []engine.Task
+		for _, t := range leafTasks {
+			if !merged[t.ID] {
+				kept = append(kept, t)
+			}
+		}
+		leafTasks = kept
+	}
+	if cfg.Sample > 0 {
+		seed := cfg.Seed
+		if seed == 0 && cfg.Deterministic {
+			seed = 1
+		} else if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		leafTasks = engine.SampleTasks(leafTasks, cfg.Sample, seed)
+		engine.Log.Info("Sampling %d tasks with --seed %d", len(leafTasks), seed)
+	}
+	engine.Log.Info("Processing %d leaf tasks (of %d fetched)", len(leafTasks), len(tasks))
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupted := handleSignals(cancel)
+	processCtx := runCtx
+	if cfg.Deadline > 0 {
+		var cancelDeadline context.CancelFunc
+		processCtx, cancelDeadline = context.WithTimeout(runCtx, time.Duration(cfg.Deadline))
+		defer cancelDeadline()
+	}
+
+	opts := []engine.ProcessOption{
+		engine.WithGracePeriod(time.Duration(cfg.GracePeriod)),
+		engine.WithRetryBudget(engine.NewRetryBudget(cfg.MaxTotalRetries)),
+		engine.WithOrderedResults(),
+	}
+	if !cfg.Quiet {
+		opts = append(opts, engine.WithProgress(progressLine(os.Stderr, 500*time.Millisecond)))
+	}
+	if cfg.AdaptiveConcurrency {
+		opts = append(opts, engine.WithAdaptiveConcurrency(engine.NewAdaptiveLimiter(cfg.MinConcurrency, cfg.Concurrency)))
+	}
+	if cfg.RatePerMinute > 0 {
+		opts = append(opts, engine.WithRateLimiter(engine.NewRateLimiter(cfg.RatePerMinute)))
+	}
+	decisions, err := engine.ProcessTasksParallelContext(processCtx, leafTasks, inertiaCtx, cfg.Concurrency, opts...)
+	if err != nil {
+		var parseErr *engine.ParseError
+		if errors.As(err, &parseErr) {
+			fmt.Fprintf(os.Stderr, "Raw LLM response for task %s:\n%s\n", parseErr.TaskID, parseErr.Response)
+		}
+		fatal("Aborting run: %v", err)
+	}
+	unprocessed := len(leafTasks) - len(decisions)
+	truncated := unprocessed > 0 && !interrupted.Load() && errors.Is(processCtx.Err(), context.DeadlineExceeded)
+	if truncated {
+		engine.Log.Warn("Deadline of %s reached: %d of %d tasks left unprocessed", cfg.Deadline, unprocessed, len(leafTasks))
+	}
+	if engine.Cache != nil {
+		if err := engine.Cache.Save(); err != nil {
+			engine.Log.Error("Failed to save decision cache: %v", err)
+		}
+	}
+	if cfg.MinScore > 0 {
+		decisions = engine.ApplyScoreThreshold(decisions, cfg.MinScore)
+	}
+	decisions = engine.ApplyAgeThresholds(decisions, cfg.DecomposeAgeDays, cfg.IceBoxAgeDays)
+	decisions = append(decisions, merges...)
+	// Merged duplicates are no longer in leafTasks, but their labels still count.
+	decisions = engine.ApplyLabelOverrides(decisions, tasks, cfg.LabelOverrides)
+	decisions = engine.FilterExecutableActions(decisions, cfg.AllowActions)
+	taskCtxs := make([]engine.TaskContext, len(leafTasks))
+	for i, t := range leafTasks {
+		taskCtxs[i] = engine.ContextualizeTask(t, inertiaCtx)
+	}
+	decisions = engine.ReconcileDecisions(decisions, taskCtxs)
+	diagnostics := engine.BuildMatchDiagnostics(taskCtxs, inertiaCtx.Gazetteer)
+	engine.Log.Info("%d gazetteer entities matched no task", len(diagnostics.UnmatchedEntities))
+	decisions = engine.CapDecisionsPerProject(decisions, leafTasks, cfg.MaxPerProject)
+	decisions = engine.CapTotalMutations(decisions, cfg.MaxMutations)
+	if cfg.Output == "json" {
+		if err := engine.PrintDecisionsJSON(os.Stdout, decisions); err != nil {
+			engine.Log.Error("Failed to print decisions: %v", err)
+		}
+	} else if cfg.Verbose {
+		for _, d := range decisions {
+			engine.Log.Info("[%s] %s (inertia %.1f): %s", d.TaskID, d.Action, d.InertiaScore, d.Reasoning)
+		}
+	}
+
+	if prior != nil {
+		// Keep stdout parseable under --output json.
+		out := os.Stdout
+		if cfg.Output == "json" {
+			out = os.Stderr
+		}
+		fmt.Fprint(out, engine.FormatReportDiff(engine.DiffReports(prior.Decisions, append(decisions, filtered...))))
+	}
+
+	var previews []engine.DryRunResult
+	if interrupted.Load() {
+		engine.Log.Warn("Interrupted after %d of %d tasks: no td commands executed", len(decisions), len(leafTasks))
+	} else if cfg.Sample > 0 {
+		fmt.Print(engine.FormatActionHistogram(decisions))
+		engine.Log.Info("Sample run: no td commands executed")
+	} else if cfg.ScoreOnly {
+		if cfg.Output != "json" {
+			fmt.Print(engine.FormatScoreTable(engine.RankByInertia(decisions)))
+		}
+	} else if cfg.DryRun {
+		previews = engine.PreviewDecisions(decisions)
+		for _, p := range previews {
+			for _, cmd := range p.Commands {
+				engine.Log.Info("Would run: %s", strings.Join(cmd, " "))
+			}
+		}
+		engine.Log.Info("Dry run: no td commands executed")
+	} else {
+		if cfg.Interactive {
+			decisions = engine.FilterApproved(decisions, &engine.PromptApprover{In: os.Stdin, Out: os.Stderr})
+		}
+		if cfg.UndoLog != "" {
+			engine.UndoRecorder = &engine.UndoLog{}
+		}
+		if cfg.Journal != "" {
+			if engine.ActiveJournal, err = engine.CreateJournal(cfg.Journal, decisions); err != nil {
+				fatal("Failed to create journal: %v", err)
+			}
+		}
+		engine.ExecuteDecisionsParallelContext(runCtx, decisions)
+		if engine.ActiveJournal != nil {
+			if err := engine.ActiveJournal.Close(); err != nil {
+				engine.Log.Error("Failed to close journal: %v", err)
+			}
+			engine.Log.Info("Journal written to %s", cfg.Journal)
+		}
+		if cfg.StateFile != "" {
+			fetched := tasks
+			if truncated {
+				fetched = nil
+			}
+			runState.Advance(fetched, decisions)
+			if err := engine.SaveRunState(cfg.StateFile, runState); err != nil {
+				engine.Log.Error("Failed to save state file: %v", err)
+			}
+		}
+		if engine.UndoRecorder != nil {
+			if err := engine.UndoRecorder.Write(cfg.UndoLog); err != nil {
+				engine.Log.Error("Failed to write undo log: %v", err)
+			} else {
+				engine.Log.Info("Undo log written to %s", cfg.UndoLog)
+			}
+		}
+	}
+
+	if cfg.Report != "" {
+		report := engine.RunReport{
+			Date:        inertiaCtx.Date,
+			GeneratedAt: engine.NowFunc(),
+			DryRun:      cfg.DryRun || cfg.ScoreOnly || cfg.Sample > 0,
+			Decisions:   append(decisions, filtered...),
+			Unprocessed: unprocessed,
+			Previews:    previews,
+			Duplicates:  duplicates,
+			Diagnostics: &diagnostics,
+		}
+		if err := engine.WriteReport(cfg.Report, report); err != nil {
+			fatal("Failed to write report: %v", err)
+		}
+		reportWritten(cfg)
+	}
+
+	if cfg.Output != "json" && !cfg.ScoreOnly && cfg.Sample == 0 && !cfg.Quiet {
+		fmt.Print(engine.FormatSummary(decisions))
+	}
+	engine.Log.Info("LLM latency: %s", engine.LLMLatency.Summary())
+	m := engine.CollectMetrics(decisions, len(tasks), time.Since(started))
+	if cfg.Metrics != "" {
+		if err := engine.WriteMetrics(m, cfg.Metrics); err != nil {
+			engine.Log.Error("Failed to write metrics: %v", err)
+		}
+	}
+	if cfg.NotifyURL != "" {
+		summary := engine.NewRunSummary(inertiaCtx.Date, cfg.DryRun || cfg.ScoreOnly || cfg.Sample > 0, m)
+		if err := engine.Notify(cfg.NotifyURL, summary, nil); err != nil {
+			engine.Log.Warn("Failed to send run notification: %v", err)
+		}
+	}
+
+	if interrupted.Load() {
+		os.Exit(130)
+	}
+	if truncated {
+		os.Exit(exitDeadline)
+	}
+}
+
+// exitDeadline is the exit status of a run cut short by --deadline, as
+// timeout(1) uses.
+const exitDeadline = 124
+
+// runResume finishes the decisions a journaled run left undone.
+func runResume(path string) {
+	executed, err := engine.ResumeJournal(context.Background(), path)
+	engine.Log.Info("Resumed %d decisions from %s", executed, path)
+	if err != nil {
+		fatal("%v", err)
+	}
+}
+
+// runUndo replays the inverse of every mutation in the undo log at path.
+func runUndo(path string) {
+	entries, err := engine.ReadUndoLog(path)
+	if err != nil {
+		fatal("Failed to read undo log: %v", err)
+	}
+	failed := 0
+	for _, cmd := range engine.BuildUndoCommands(entries) {
+		if err := engine.CommandRunner.Run(cmd[0], cmd[1:]...); err != nil {
+			engine.Log.Error("Undo command %v failed: %v", cmd, err)
+			failed++
+		}
+	}
+	for _, e := range entries {
+		switch {
+		case engine.CanUndo(e):
+		case e.Field == engine.UndoFieldSubtask:
+			engine.Log.Warn("Cannot undo subtask %q added under task %s; remove it by hand", e.NewValue, e.TaskID)
+		default:
+			engine.Log.Warn("Cannot undo %s change on task %s from %q to %q; revert it by hand", e.Field, e.TaskID, e.OldValue, e.NewValue)
+		}
+	}
+	if failed > 0 {
+		fatal("%d undo commands failed", failed)
+	}
+}
+
+// runIceBox ice-boxes old, weakly rooted tasks without consulting the LLM.
+func runIceBox(cfg *engine.Config, tasks []engine.Task, inertiaCtx *engine.InertiaContext) {
+	decisions := engine.SelectForIceBox(tasks, inertiaCtx, engine.IceBoxOptions{
+		MinAgeDays: cfg.IceBoxAgeDays,
+		MaxWeight:  cfg.IceBoxMaxWeight,
+	})
+	engine.Log.Info("Selected %d of %d tasks for the ice-box", len(decisions), len(tasks))
+	for _, d := range decisions {
+		engine.Log.Info("[%s] %s: %s", d.TaskID, d.CurrentContent, d.Reasoning)
+	}
+	if cfg.DryRun {
+		engine.Log.Info("Dry run: no td commands executed")
+	} else {
+		engine.ExecuteDecisionsParallel(decisions)
+	}
+	if cfg.Report != "" {
+		report := engine.RunReport{
+			Date:        inertiaCtx.Date,
+			GeneratedAt: engine.NowFunc(),
+			DryRun:      cfg.DryRun,
+			Decisions:   decisions,
+		}
+		if err := engine.WriteReport(cfg.Report, report); err != nil {
+			fatal("Failed to write report: %v", err)
+		}
+		reportWritten(cfg)
+	}
+}
+
+// reportWritten confirms the report path. Under --quiet, where info logs are
+// dropped, it goes to stdout so cron mail still says where the report is.
+func reportWritten(cfg *engine.Config) {
+	if cfg.Quiet {
+		fmt.Printf("Report written to %s\n", cfg.Report)
+		return
+	}
+	engine.Log.Info("Report written to %s", cfg.Report)
+}
+
+// fatal logs through the engine logger, so --log-format applies, then exits.
+func fatal(format string, args ...any) {
+	engine.Log.Error(format, args...)
+	os.Exit(1)
+}
+
+// handleSignals cancels the run on the first SIGINT/SIGTERM so in-flight
+// tasks can finish, and force-exits on the second.
+func handleSignals(cancel context.CancelFunc) *atomic.Bool {
+	var interrupted atomic.Bool
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		interrupted.Store(true)
+		engine.Log.Warn("Interrupt received: finishing in-flight tasks (signal again to force exit)")
+		cancel()
+		<-sigs
+		engine.Log.Error("Forced exit")
+		os.Exit(130)
+	}()
+	return &interrupted
+}
+
+// progressLine rewrites a single status line on w, at most once per interval
+// apart from the final update.
+func progressLine(w io.Writer, interval time.Duration) engine.ProgressFunc {
+	var last time.Time
+	return func(completed, total int) {
+		now := time.Now()
+		if completed < total && now.Sub(last) < interval {
+			return
+		}
+		last = now
+		fmt.Fprintf(w, "\rProcessed %d/%d tasks", completed, total)
+		if completed == total {
+			fmt.Fprintln(w)
+		}
+	}
+}